@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"risk/experiments"
+	"risk/game"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage:
+  risk                            run the baseline experiments
+  risk experiment <name>...       run experiments by name
+  risk mapcheck <source>          validate a map
+  risk mapdiff <source> <source>  compare two maps
+
+An experiment name is one of %s.
+
+A map source is the name of a built-in map (%s), a JSON or YAML map
+definition, or a .txt list of borders.
+`
+
+// experimentRun is an experiment that can be run by name
+type experimentRun struct {
+	name string
+	run  func()
+}
+
+// experimentRuns are the baseline experiments run by default followed by the others
+var experimentRuns = []experimentRun{
+	{"parallelism", experiments.RunParallelismExperiment},
+	{"cutoff", experiments.RunCutoffExperiment},
+	{"evaluation", experiments.RunEvaluationExperiment},
+	{"elo", experiments.RunEloExperiment},
+	{"map", experiments.RunMapExperiment},
+	{"generalization", experiments.RunGeneralizationExperiment},
+	{"fortify", experiments.RunFortifyExperiment},
+	{"rollout", experiments.RunRolloutExperiment},
+	{"selection", experiments.RunSelectionExperiment},
+}
+
+// runCommand executes a command line subcommand and returns the exit code
+func runCommand(name string, args []string) int {
+	switch {
+	case name == "experiment" && len(args) > 0:
+		return runExperiments(args)
+	case name == "mapcheck" && len(args) == 1:
+		return checkMap(args[0])
+	case name == "mapdiff" && len(args) == 2:
+		return diffMaps(args[0], args[1])
+	default:
+		printUsage()
+		return 2
+	}
+}
+
+func printUsage() {
+	var names []string
+	for _, experiment := range experimentRuns {
+		names = append(names, experiment.name)
+	}
+	fmt.Fprintf(os.Stderr, usage, strings.Join(names, ", "), strings.Join(game.BuiltinMapNames(), ", "))
+}
+
+// runExperiments runs the named experiments in the order given, checking every
+// name before running any
+func runExperiments(names []string) int {
+	var runs []func()
+	for _, name := range names {
+		index := slices.IndexFunc(experimentRuns, func(experiment experimentRun) bool {
+			return experiment.name == name
+		})
+		if index < 0 {
+			fmt.Fprintf(os.Stderr, "unknown experiment %q\n", name)
+			printUsage()
+			return 2
+		}
+		runs = append(runs, experimentRuns[index].run)
+	}
+
+	log.Info().Msgf("number of CPUs: %d", runtime.NumCPU())
+	for _, run := range runs {
+		run()
+	}
+	return 0
+}
+
+func checkMap(source string) int {
+	m, err := loadMapSource(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	err = m.Validate()
+	if errs, ok := err.(game.ValidationErrors); ok {
+		problems := 0
+		for _, e := range errs {
+			if len(m.Regions) == 0 && e.Kind == game.UnknownRegion {
+				continue // Reported once below
+			}
+			fmt.Println(describeProblem(m, e))
+			problems++
+		}
+		if len(m.Regions) == 0 {
+			fmt.Println("no regions defined")
+			problems++
+		}
+		if problems > 0 {
+			return 1
+		}
+	}
+	fmt.Printf("%s: %d cantons, %d regions, no problems found\n", source, len(m.Cantons), len(m.Regions))
+	return 0
+}
+
+func diffMaps(source1, source2 string) int {
+	m1, err := loadMapSource(source1)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	m2, err := loadMapSource(source2)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	diffs := game.DiffMaps(m1, m2)
+	if len(diffs) == 0 {
+		return 0
+	}
+	fmt.Printf("--- %s\n+++ %s\n", source1, source2)
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	return 1
+}
+
+// loadMapSource loads a built-in map by name or a map file by path
+func loadMapSource(source string) (*game.Map, error) {
+	if m, err := game.BuiltinMap(source); err == nil {
+		return m, nil
+	}
+
+	if filepath.Ext(source) == ".txt" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open border list: %w", err)
+		}
+		defer f.Close()
+		return game.LoadBorderList(f)
+	}
+	return game.LoadMapFile(source)
+}
+
+// describeProblem spells out a validation problem with canton abbreviations
+func describeProblem(m *game.Map, e game.ValidationError) string {
+	name := func(cantonID int) string {
+		if canton, ok := m.Cantons[cantonID]; ok {
+			return canton.Abbreviation
+		}
+		return fmt.Sprintf("#%d", cantonID)
+	}
+
+	switch e.Kind {
+	case game.AsymmetricBorder:
+		return fmt.Sprintf("%s: %s lists %s but not the other way around", e.Kind, name(e.CantonID), name(e.OtherID))
+	case game.UnknownCanton:
+		if e.CantonID >= 0 {
+			return fmt.Sprintf("%s: %s borders %s", e.Kind, name(e.CantonID), name(e.OtherID))
+		}
+		return fmt.Sprintf("%s: region %d lists %s", e.Kind, e.RegionID, name(e.OtherID))
+	case game.SelfLoop, game.Disconnected, game.NonDenseIDs:
+		return fmt.Sprintf("%s: %s", e.Kind, name(e.CantonID))
+	default:
+		return e.Error()
+	}
+}
@@ -32,7 +32,7 @@ func LegacyEngine(players []string, agents []MCTSAdapter, m *game.Map, r game.Ru
 		panic("need at least two players")
 	}
 
-	state := game.NewGameState(m, r)
+	state := game.NewGameState(m, r, game.WithPlayers(len(players)))
 
 	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	firstPlayer := rng.Intn(len(players)) + 1
@@ -62,7 +62,7 @@ func (e *engine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 	start := time.Now()
 
 	for e.State.Winner() == "" && turnCount <= MaxTurns {
-		currentPlayerID := e.State.CurrentPlayer
+		currentPlayerID := e.State.Decider()
 		agentIndex := currentPlayerID - 1
 
 		// Debug print
@@ -72,7 +72,7 @@ func (e *engine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 			updates[agentIndex])
 		moveMetrics = append(moveMetrics, metrics.MoveMetric{
 			Step:         turnCount,
-			Player:       e.State.CurrentPlayer,
+			Player:       currentPlayerID,
 			SearchMetric: searchMetrics,
 		})
 
@@ -106,6 +106,8 @@ func (e *engine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 		StartTime:      start,
 		EndTime:        end,
 		Duration:       end.Sub(start),
+		Placements:     e.State.Placements(),
+		Map:            e.State.Map.Name,
 	}
 
 	return e.State.Winner(), gameMetric, moveMetrics
@@ -125,8 +127,8 @@ func (ma *MCTSAdapter) FindMove(gs *game.GameState, recentUpdates []Update) (gam
 	}
 	candidate, metrics := ma.InternalAgent.FindMove(gs, segments...)
 
-	if !game.IsMoveValidForPhase(gs.Phase, candidate) {
-		// fmt.Printf("[MCTSAdapter] MCTS returned an invalid move for Phase=%d => forcing pass.\n", gs.Phase)
+	if err := gs.ValidateMove(candidate); err != nil {
+		log.Error().Err(err).Msg("MCTS returned an invalid move, falling back to the first legal move")
 		fallbackMoves := gs.LegalMoves()
 		if len(fallbackMoves) == 0 {
 			panic("No legal moves at all!")
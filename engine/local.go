@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"fmt"
 	"risk/experiments/metrics"
 	"risk/game"
 	"risk/searcher"
@@ -12,21 +13,64 @@ import (
 
 // Evaluation engine runs a game locally and collects performance metrics
 type localEngine struct {
-	agents []agent.Agent
+	agents  []agent.Agent
+	m       *game.Map
+	rules   game.Rules
+	options []game.Option // Game setup applied to every game
 }
 
-func NewLocalEngine(agents []agent.Agent) Engine {
-	if len(agents) != 2 {
-		panic("need two agents to play a game")
+type Option func(e *localEngine)
+
+// WithMap sets the map the games are played on, the Swiss map by default
+func WithMap(m *game.Map) Option {
+	return func(e *localEngine) {
+		e.m = m
+	}
+}
+
+// WithRules sets the rules the games are played by, the standard rules by default
+func WithRules(rules game.Rules) Option {
+	return func(e *localEngine) {
+		e.rules = rules
+	}
+}
+
+// WithSeed seeds every game so that, with seeded agents, the same game is played on each run
+func WithSeed(seed int64) Option {
+	return func(e *localEngine) {
+		e.options = append(e.options, game.WithSeed(seed))
 	}
-	return &localEngine{agents: agents}
+}
+
+// WithSetupPhase has the players claim the cantons and place their starting
+// armies themselves, instead of dealing the cantons at random
+func WithSetupPhase() Option {
+	return func(e *localEngine) {
+		e.options = append(e.options, game.WithSetupPhase())
+	}
+}
+
+func NewLocalEngine(agents []agent.Agent, options ...Option) Engine {
+	if len(agents) < game.MinPlayers || len(agents) > game.MaxPlayers {
+		panic(fmt.Sprintf("need %d to %d agents to play a game, got %d", game.MinPlayers, game.MaxPlayers, len(agents)))
+	}
+	e := &localEngine{agents: agents}
+	for _, option := range options {
+		option(e)
+	}
+	if e.m == nil {
+		e.m = game.CreateMap()
+	}
+	if e.rules == nil {
+		e.rules = game.NewStandardRules()
+	}
+	return e
 }
 
 func (e *localEngine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 	// Initialize a new game
-	m := game.CreateMap()
-	rules := game.NewStandardRules()
-	state := game.NewGameState(m, rules)
+	options := append([]game.Option{game.WithPlayers(len(e.agents))}, e.options...)
+	state := game.NewGameState(e.m, e.rules, options...)
 
 	startingPlayer := state.CurrentPlayer
 	log.Info().Msgf("player %d is starting", startingPlayer)
@@ -34,44 +78,41 @@ func (e *localEngine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 	// Ask agents to play moves till there's a winner or max moves is exceeded
 	var moveMetrics []metrics.MoveMetric
 	start := time.Now()
-	var turnUpdates []searcher.Segment
-	prevPlayer := startingPlayer
+	updates := make([][]searcher.Segment, len(e.agents)) // Moves played since each agent's last move
 
 	numMoves := 1
 	for state.Winner() == "" && numMoves <= MaxMoves {
 		// Find the next move
-		currPlayer := state.CurrentPlayer
-		relevantUpdates := turnUpdates
-		if relevantUpdates != nil && currPlayer == prevPlayer {
-			relevantUpdates = turnUpdates[len(turnUpdates)-1:]
-		}
-		move, searchMetric := e.agents[currPlayer-1].FindMove(state, relevantUpdates...)
-		if !game.IsMoveValidForPhase(state.Phase, move) { // TODO: remove
-			log.Error().Msgf("invalid move %+v for phase %d at step %d", move, state.Phase, numMoves)
-			move = state.LegalMoves()[0]
-		}
+		currPlayer := state.Decider()
+		move, searchMetric := e.agents[currPlayer-1].FindMove(state, updates[currPlayer-1]...)
+		updates[currPlayer-1] = nil
 		// Collect move metrics
 		moveMetrics = append(moveMetrics, metrics.MoveMetric{
 			Step:         numMoves,
 			Player:       currPlayer,
 			SearchMetric: searchMetric,
 		})
-		// Play the next move
-		nextState, ok := state.Play(move).(*game.GameState)
+		// Play the next move, falling back to the first legal move if the agent's move is rejected
+		next, err := state.TryPlay(move)
+		if err != nil {
+			log.Error().Err(err).Msgf("agent of player %d played an invalid move at step %d", currPlayer, numMoves)
+			move = state.LegalMoves()[0]
+			next = state.Play(move)
+		}
+		nextState, ok := next.(*game.GameState)
 		if !ok {
 			panic("unexpected state type")
 		}
-		// Collect moves played during this player's turn
-		if currPlayer != prevPlayer { // Reset when turn changes
-			turnUpdates = []searcher.Segment{}
-		}
-		turnUpdates = append(turnUpdates, searcher.Segment{
+		// Collect the move for every agent to catch up on
+		segment := searcher.Segment{
 			Move:      move,
 			StateHash: nextState.Hash(),
-		})
+		}
+		for i := range updates {
+			updates[i] = append(updates[i], segment)
+		}
 
 		state = nextState
-		prevPlayer = currPlayer
 		numMoves++
 	}
 
@@ -88,6 +129,8 @@ func (e *localEngine) Run() (string, metrics.GameMetric, []metrics.MoveMetric) {
 		EndTime:        end,
 		Duration:       end.Sub(start),
 		TotalMoves:     numMoves - 1,
+		Placements:     state.Placements(),
+		Map:            e.m.Name,
 	}
 
 	return winner, gameMetric, moveMetrics
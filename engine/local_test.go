@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"risk/experiments/metrics"
+	"risk/game"
+	"risk/searcher"
+	"risk/searcher/agent"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// randomAgent plays a uniformly random legal move
+type randomAgent struct {
+	rng *rand.Rand
+}
+
+func newRandomAgent(seed int64) randomAgent {
+	return randomAgent{rng: rand.New(rand.NewSource(seed))}
+}
+
+// newRandomAgents returns the given number of random agents, seeded in turn
+func newRandomAgents(numAgents int) []agent.Agent {
+	agents := make([]agent.Agent, numAgents)
+	for i := range agents {
+		agents[i] = newRandomAgent(int64(i))
+	}
+	return agents
+}
+
+func (a randomAgent) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
+	moves := state.LegalMoves()
+	return moves[a.rng.Intn(len(moves))], metrics.SearchMetric{}
+}
+
+// cardObserver wraps an agent to check the card flow in every state it is asked to play
+type cardObserver struct {
+	agent.Agent
+	t         *testing.T
+	deckSize  int
+	maxHand   int
+	exchanges int
+}
+
+func (o *cardObserver) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
+	gs := state.(*game.GameState)
+
+	total := len(gs.Cards) + len(gs.DiscardedCards)
+	for playerID, hand := range gs.PlayerHands {
+		total += len(hand)
+		if playerID == gs.CurrentPlayer && len(hand) > o.maxHand {
+			o.maxHand = len(hand)
+		}
+	}
+	require.Equal(o.t, o.deckSize, total, "Cards should never be created or lost")
+	if gs.Exchanges > o.exchanges {
+		o.exchanges = gs.Exchanges
+	}
+
+	return o.Agent.FindMove(state, updates...)
+}
+
+func TestLocalEngineCardFlow(t *testing.T) {
+	deckSize := len(game.CreateMap().Cantons) + game.DefaultWildCards
+
+	for numPlayers := game.MinPlayers; numPlayers <= 4; numPlayers++ {
+		observers := make([]*cardObserver, numPlayers)
+		agents := make([]agent.Agent, numPlayers)
+		for i := range agents {
+			observers[i] = &cardObserver{Agent: newRandomAgent(int64(i)), t: t, deckSize: deckSize}
+			agents[i] = observers[i]
+		}
+
+		// Cards are only traded once hands fill up, so play a few games
+		maxHand, exchanges := 0, 0
+		for i := 0; i < 5; i++ {
+			_, gameMetric, _ := NewLocalEngine(agents, WithSeed(int64(i))).Run()
+			require.Len(t, gameMetric.Placements, numPlayers, "Every player should be placed")
+		}
+		for _, o := range observers {
+			maxHand = max(maxHand, o.maxHand)
+			exchanges = max(exchanges, o.exchanges)
+		}
+
+		require.Greater(t, maxHand, 0, "Players should be awarded cards for conquests")
+		require.Greater(t, exchanges, 0, "Players should trade in sets")
+	}
+}
+
+// turnChecker wraps the agent of a player to check that it is asked to move
+// when its player decides, and told every move played since its last move,
+// starting with its own. It replays the game from the same seed to check the
+// moves it is told.
+type turnChecker struct {
+	agent.Agent
+	t        *testing.T
+	playerID int
+	replay   game.State
+	last     *played // Last move played in the game, shared by the agents
+}
+
+type played struct {
+	state game.State
+	move  game.Move
+}
+
+func (c *turnChecker) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
+	require.Equal(c.t, c.playerID, state.(*game.GameState).Decider(), "Should ask the player deciding the move")
+	for _, update := range updates {
+		c.replay = c.replay.Play(update.Move)
+		require.Equal(c.t, c.replay.Hash(), update.StateHash, "Should tell the state each move led to")
+	}
+	require.Equal(c.t, c.replay.Hash(), state.Hash(), "Should tell every move played since the agent's last move")
+
+	move, metric := c.Agent.FindMove(state, updates...)
+	*c.last = played{state: state, move: move}
+	return move, metric
+}
+
+func TestLocalEngineTurns(t *testing.T) {
+	const seed = 7
+	m, rules := game.CreateMap(), game.NewStandardRules()
+	var last played
+	agents := newRandomAgents(3)
+	for i := range agents {
+		agents[i] = &turnChecker{
+			Agent:    agents[i],
+			t:        t,
+			playerID: i + 1,
+			replay:   game.NewGameState(m, rules, game.WithPlayers(len(agents)), game.WithSeed(seed)),
+			last:     &last,
+		}
+	}
+
+	winner, gameMetric, moveMetrics := NewLocalEngine(agents, WithMap(m), WithRules(rules), WithSeed(seed)).Run()
+
+	final := last.state.Play(last.move).(*game.GameState)
+	require.NotEmpty(t, winner, "Should play the game to the end")
+	require.Equal(t, final.Winner(), winner)
+	require.Equal(t, winner, gameMetric.Winner)
+	for _, owner := range final.Ownership {
+		require.Equal(t, gameMetric.Placements[0], owner, "Winner should hold every canton")
+	}
+	eliminated := slices.Clone(gameMetric.Placements[1:])
+	slices.Reverse(eliminated)
+	require.Equal(t, final.Eliminated, eliminated, "Should place the others in reverse order of elimination")
+	require.Len(t, moveMetrics, gameMetric.TotalMoves)
+}
+
+func TestLocalEngineMap(t *testing.T) {
+	agents := newRandomAgents(3)
+
+	winner, gameMetric, _ := NewLocalEngine(agents, WithMap(game.CreateClassicMap()), WithSeed(1)).Run()
+
+	require.NotEmpty(t, winner, "Should play the game to the end")
+	require.Equal(t, "classic", gameMetric.Map)
+	require.Len(t, gameMetric.Placements, len(agents), "Every player should be placed")
+}
+
+// recorder wraps an agent to record every move it plays along with the resulting state
+type recorder struct {
+	agent.Agent
+	moves *[]string
+}
+
+func (r recorder) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
+	move, metric := r.Agent.FindMove(state, updates...)
+	*r.moves = append(*r.moves, fmt.Sprintf("%s %+v %d", state.Player(), move, state.Hash()))
+	return move, metric
+}
+
+func TestLocalEngineSeed(t *testing.T) {
+	play := func(seed int64) (string, []string, []int) {
+		var moves []string
+		agents := make([]agent.Agent, 3)
+		for i := range agents {
+			mcts := searcher.NewMCTS(1, searcher.WithEpisodes(20), searcher.WithCutoff(10), searcher.WithSeed(seed+int64(i)))
+			agents[i] = recorder{Agent: agent.NewEvaluationAgent(mcts), moves: &moves}
+		}
+		winner, gameMetric, _ := NewLocalEngine(agents, WithSeed(seed)).Run()
+		return winner, moves, gameMetric.Placements
+	}
+
+	winner1, moves1, placements1 := play(42)
+	winner2, moves2, placements2 := play(42)
+
+	require.Equal(t, winner1, winner2)
+	require.Equal(t, moves1, moves2, "Same seeds should replay the same game")
+	require.Equal(t, placements1, placements2)
+
+	_, moves3, _ := play(43)
+	require.NotEqual(t, moves1, moves3, "Different seeds should play different games")
+}
+
+func TestLocalEngineSetupPhase(t *testing.T) {
+	agents := newRandomAgents(3)
+
+	winner, gameMetric, _ := NewLocalEngine(agents, WithSetupPhase(), WithSeed(1)).Run()
+
+	require.NotEmpty(t, winner, "Should play the game to the end")
+	require.Len(t, gameMetric.Placements, len(agents), "Every player should be placed")
+}
+
+func TestLocalEngineRules(t *testing.T) {
+	agents := newRandomAgents(3)
+	rules := game.NewStandardRules()
+	rules.Occupation = true
+
+	winner, gameMetric, _ := NewLocalEngine(agents, WithRules(rules), WithSeed(1)).Run()
+
+	require.NotEmpty(t, winner, "Should play the game to the end")
+	require.Len(t, gameMetric.Placements, len(agents), "Every player should be placed")
+}
@@ -29,12 +29,8 @@ func RunParallelismExperiment() {
 		{ID: 5, Goroutines: 32, Duration: baseline.Duration},
 		{ID: 6, Goroutines: 64, Duration: baseline.Duration},
 	}
-	var matchUps [][]metrics.AgentConfig
-	for _, config := range expConfigs {
-		matchUps = append(matchUps, []metrics.AgentConfig{baseline, config})
-	}
 
-	runExperiment("parallelism", append(expConfigs, baseline), matchUps, NumBenchmarkGames)
+	runAgainstBaseline("parallelism", baseline, expConfigs, []*game.Map{game.CreateMap()})
 }
 
 const SelectedConcurrency = 8
@@ -57,7 +53,7 @@ func RunCutoffExperiment() {
 		matchUps = append(matchUps, []metrics.AgentConfig{baseline, config})
 	}
 
-	runExperiment("cutoff", expConfigs, matchUps, NumBenchmarkGames)
+	runExperiment("cutoff", []*game.Map{game.CreateMap()}, expConfigs, matchUps, NumBenchmarkGames)
 }
 
 func RunEvaluationExperiment() {
@@ -76,7 +72,7 @@ func RunEvaluationExperiment() {
 		matchUps = append(matchUps, []metrics.AgentConfig{baseline, config})
 	}
 
-	runExperiment("evaluation", expConfigs, matchUps, NumBenchmarkGames)
+	runExperiment("evaluation", []*game.Map{game.CreateMap()}, expConfigs, matchUps, NumBenchmarkGames)
 }
 
 const StrongestCutoff = 25 // TODO: pick cutoff depth with the highest playing strength from cutoff experiment
@@ -89,7 +85,146 @@ func RunEloExperiment() {
 		{ID: 4, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateBorderStrength},
 	}
 
-	// Run games between each pair of agents in round-robin
+	runMatchups("elo", configs, []*game.Map{game.CreateMap()})
+}
+
+// RunMapExperiment repeats the Elo round-robin on every built-in map to compare
+// how the agents' relative strength carries over to boards of different size
+func RunMapExperiment() {
+	configs := []metrics.AgentConfig{
+		{ID: 1, Goroutines: 1, Duration: TimeBudget},
+		{ID: 2, Goroutines: SelectedConcurrency, Duration: TimeBudget},
+		{ID: 3, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateResources},
+	}
+
+	for _, name := range game.BuiltinMapNames() {
+		m, err := game.BuiltinMap(name)
+		if err != nil {
+			panic(fmt.Sprintf("failed to create map: %v", err))
+		}
+		runMatchups("map-"+name, configs, []*game.Map{m})
+	}
+}
+
+// MapFamilies are the generated map families swept by the generalization experiment
+var MapFamilies = []game.GeneratorConfig{
+	{Territories: 24, Regions: 4, AverageDegree: 3},
+	{Territories: 42, Regions: 6, AverageDegree: 4},
+	{Territories: 64, Regions: 8, AverageDegree: 4.5},
+}
+
+const NumGeneratedMaps = 10 // Per map family
+
+// RunGeneralizationExperiment repeats the Elo round-robin on procedurally generated
+// maps, seeding the generator so every run sweeps the same maps
+func RunGeneralizationExperiment() {
+	configs := []metrics.AgentConfig{
+		{ID: 1, Goroutines: SelectedConcurrency, Duration: TimeBudget},
+		{ID: 2, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateResources},
+		{ID: 3, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateBorderStrength},
+	}
+
+	for _, family := range MapFamilies {
+		var maps []*game.Map
+		for seed := int64(1); seed <= NumGeneratedMaps; seed++ {
+			family.Seed = seed
+			m, err := game.GenerateMap(family)
+			if err != nil {
+				panic(fmt.Sprintf("failed to generate map: %v", err))
+			}
+			maps = append(maps, m)
+		}
+		name := fmt.Sprintf("generalization-%d-%d", family.Territories, family.Regions)
+		runMatchups(name, configs, maps)
+	}
+}
+
+// FortifyVariants are the fortify rules swept by the fortify experiment, by name
+var FortifyVariants = []struct {
+	Name      string
+	Fortify   game.FortifyRule
+	Maneuvers int
+}{
+	{Name: "connected", Fortify: game.ConnectedFortify},
+	{Name: "adjacent", Fortify: game.AdjacentFortify},
+	{Name: "unlimited", Fortify: game.ConnectedFortify, Maneuvers: game.UnlimitedManeuvers},
+	{Name: "source", Fortify: game.SourceFortify, Maneuvers: game.UnlimitedManeuvers},
+}
+
+// RunFortifyExperiment repeats the Elo round-robin under each fortify rule to
+// compare how the rule changes the length of games and the agents' relative strength
+func RunFortifyExperiment() {
+	configs := []metrics.AgentConfig{
+		{ID: 1, Goroutines: 1, Duration: TimeBudget},
+		{ID: 2, Goroutines: SelectedConcurrency, Duration: TimeBudget},
+		{ID: 3, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateResources},
+	}
+
+	for _, variant := range FortifyVariants {
+		rules := game.NewStandardRules()
+		rules.Fortify = variant.Fortify
+		rules.Maneuvers = variant.Maneuvers
+		runMatchups("fortify-"+variant.Name, configs, []*game.Map{game.CreateMap()}, engine.WithRules(rules))
+	}
+}
+
+// RolloutPolicies are the rollout policies compared by the rollout experiment, by name
+var RolloutPolicies = []struct {
+	Name   string
+	Policy searcher.RolloutPolicy
+}{
+	{Name: "uniform", Policy: searcher.UniformRollout{}},
+	{Name: "epsilon-greedy", Policy: searcher.NewEpsilonGreedyRollout(searcher.Epsilon, game.EvaluateResources)},
+	{Name: "favorable-attacks", Policy: searcher.FavorableAttackRollout{Ratio: searcher.FavorableRatio}},
+	{Name: "softmax", Policy: searcher.NewSoftmaxRollout()},
+}
+
+// RunRolloutExperiment plays agents rolling out with each policy against each
+// other under the same time budget, to weigh the quality of playouts against
+// their number
+func RunRolloutExperiment() {
+	var configs []metrics.AgentConfig
+	for i, policy := range RolloutPolicies {
+		configs = append(configs, metrics.AgentConfig{
+			ID: i + 1, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateResources, Rollout: policy.Name,
+		})
+	}
+
+	runMatchups("rollout", configs, []*game.Map{game.CreateMap()})
+}
+
+// TreePolicies are the selection formulas swept by the selection experiment, by name
+var TreePolicies = []struct {
+	Name   string
+	Policy searcher.TreePolicy
+}{
+	{Name: "uct-0.5", Policy: searcher.UCT{CSquared: 0.5}},
+	{Name: "uct-1", Policy: searcher.UCT{CSquared: 1}},
+	{Name: "uct-2", Policy: searcher.UCT{CSquared: 2}},
+	{Name: "uct-4", Policy: searcher.UCT{CSquared: 4}},
+	{Name: "ucb1-tuned", Policy: searcher.UCB1Tuned{}},
+	{Name: "progressive-bias", Policy: searcher.NewProgressiveBias(game.EvaluateResources)},
+	{Name: "puct", Policy: searcher.PUCT{CPuct: searcher.CPuct}},
+}
+
+// RunSelectionExperiment pairs a baseline agent selecting by UCT with the
+// default exploration constant against agents selecting by each tree policy
+func RunSelectionExperiment() {
+	baseline := metrics.AgentConfig{ID: 0, Goroutines: SelectedConcurrency, Duration: TimeBudget, Cutoff: StrongestCutoff, Evaluate: game.EvaluateResources}
+	var expConfigs []metrics.AgentConfig
+	for i, policy := range TreePolicies {
+		config := baseline
+		config.ID = i + 1
+		config.Selection = policy.Name
+		expConfigs = append(expConfigs, config)
+	}
+
+	runAgainstBaseline("selection", baseline, expConfigs, []*game.Map{game.CreateMap()})
+}
+
+// runMatchups plays every agent against every other agent in round-robin on
+// the given maps, with the engine options given such as the rules
+func runMatchups(name string, configs []metrics.AgentConfig, maps []*game.Map, options ...engine.Option) {
 	var matchUps [][]metrics.AgentConfig
 	for i, config1 := range configs {
 		for _, config2 := range configs[i+1:] {
@@ -97,16 +232,29 @@ func RunEloExperiment() {
 		}
 	}
 
-	runExperiment("elo", configs, matchUps, NumRatingGames)
+	runExperiment(name, maps, configs, matchUps, NumRatingGames, options...)
 }
 
-func runExperiment(name string, configs []metrics.AgentConfig, matchUps [][]metrics.AgentConfig, numGames int) {
+// runAgainstBaseline plays the baseline agent against each experiment agent on
+// the given maps, with the engine options given such as the rules
+func runAgainstBaseline(name string, baseline metrics.AgentConfig, expConfigs []metrics.AgentConfig, maps []*game.Map, options ...engine.Option) {
+	var matchUps [][]metrics.AgentConfig
+	for _, config := range expConfigs {
+		matchUps = append(matchUps, []metrics.AgentConfig{baseline, config})
+	}
+
+	runExperiment(name, maps, append(expConfigs, baseline), matchUps, NumBenchmarkGames, options...)
+}
+
+// runExperiment plays every matchup on the given maps, rotating through them game
+// by game, with the engine options given such as the rules
+func runExperiment(name string, maps []*game.Map, configs []metrics.AgentConfig, matchUps [][]metrics.AgentConfig, numGames int, options ...engine.Option) {
 	// Run a number of games for each matchup
 	count := 0
 	var gameRecords []metrics.GameRecord
 	var moveRecords []metrics.MoveRecord
 
-	log.Info().Msgf("starting %s experiment...", name)
+	log.Info().Msgf("starting %s experiment on %d maps...", name, len(maps))
 
 	for mi, matchup := range matchUps {
 		config1 := matchup[0]
@@ -117,7 +265,7 @@ func runExperiment(name string, configs []metrics.AgentConfig, matchUps [][]metr
 		for i := 0; i < numGames; i++ {
 			log.Info().Msgf("starting matchup %d of %d game %d of %d...", mi+1, len(matchUps), i+1, numGames)
 
-			winner, gameMetric, moveMetrics := runGame(config1, config2)
+			winner, gameMetric, moveMetrics := runGame(maps[i%len(maps)], config1, config2, options...)
 			count++
 			gameRecords = append(gameRecords, metrics.GameRecord{
 				ID:         count,
@@ -165,13 +313,13 @@ func runExperiment(name string, configs []metrics.AgentConfig, matchUps [][]metr
 	log.Info().Msg("stored move records")
 }
 
-// runGame executes a single game between two agents and returns the winner
-func runGame(config1, config2 metrics.AgentConfig) (string, metrics.GameMetric, []metrics.MoveMetric) {
+// runGame executes a single game between two agents on the given map and returns the winner
+func runGame(m *game.Map, config1, config2 metrics.AgentConfig, options ...engine.Option) (string, metrics.GameMetric, []metrics.MoveMetric) {
 	agents := []agent.Agent{
 		agent.NewEvaluationAgent(createMCTS(config1)),
 		agent.NewEvaluationAgent(createMCTS(config2)),
 	}
-	e := engine.NewLocalEngine(agents)
+	e := engine.NewLocalEngine(agents, append([]engine.Option{engine.WithMap(m)}, options...)...)
 	winner, gameMetric, moveMetrics := e.Run()
 
 	return winner, gameMetric, moveMetrics
@@ -192,7 +340,33 @@ func createMCTS(config metrics.AgentConfig) *searcher.MCTS {
 	if config.Evaluate != nil {
 		options = append(options, searcher.WithEvaluationFn(config.Evaluate))
 	}
+	if config.Rollout != "" {
+		options = append(options, searcher.WithRolloutPolicy(rolloutPolicy(config.Rollout)))
+	}
+	if config.Selection != "" {
+		options = append(options, searcher.WithTreePolicy(treePolicy(config.Selection)))
+	}
 
 	options = append(options, searcher.WithMetrics())
 	return searcher.NewMCTS(config.Goroutines, options...)
 }
+
+// rolloutPolicy returns the rollout policy of the given name
+func rolloutPolicy(name string) searcher.RolloutPolicy {
+	for _, policy := range RolloutPolicies {
+		if policy.Name == name {
+			return policy.Policy
+		}
+	}
+	panic(fmt.Sprintf("unknown rollout policy %q", name))
+}
+
+// treePolicy returns the tree policy of the given name
+func treePolicy(name string) searcher.TreePolicy {
+	for _, policy := range TreePolicies {
+		if policy.Name == name {
+			return policy.Policy
+		}
+	}
+	panic(fmt.Sprintf("unknown tree policy %q", name))
+}
@@ -29,6 +29,8 @@ type GameMetric struct {
 	EndTime        time.Time
 	Duration       time.Duration
 	TotalMoves     int
+	Placements     []int  // Player IDs from first to last place
+	Map            string // Name of the map played on
 }
 
 type Collector interface {
@@ -10,6 +10,7 @@ import (
 	"risk/game"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +21,8 @@ type AgentConfig struct {
 	Episodes   int
 	Cutoff     int
 	Evaluate   game.Evaluate
+	Rollout    string // Name of the rollout policy, uniform if empty
+	Selection  string // Name of the tree policy, UCT if empty
 }
 
 type GameRecord struct {
@@ -65,7 +68,7 @@ func (w *Writer) WriteAgentConfigs(configs []AgentConfig) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"id", "goroutines", "duration", "episodes", "cutoff", "evaluation"}
+	header := []string{"id", "goroutines", "duration", "episodes", "cutoff", "evaluation", "rollout", "selection"}
 	err = writer.Write(header)
 	if err != nil {
 		return fmt.Errorf("failed to write agent configs header: %w", err)
@@ -80,6 +83,8 @@ func (w *Writer) WriteAgentConfigs(configs []AgentConfig) error {
 			strconv.Itoa(config.Episodes),
 			strconv.Itoa(config.Cutoff),
 			getFnName(config.Evaluate),
+			config.Rollout,
+			config.Selection,
 		}
 		err = writer.Write(row)
 		if err != nil {
@@ -103,7 +108,7 @@ func (w *Writer) WriteGameRecords(records []GameRecord) error {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"id", "agent1", "agent2", "starting_player", "winner", "start_time", "end_time", "duration", "total_moves"}
+	header := []string{"id", "agent1", "agent2", "starting_player", "winner", "start_time", "end_time", "duration", "total_moves", "placements", "map"}
 	err = writer.Write(header)
 	if err != nil {
 		return fmt.Errorf("failed to write game records header: %w", err)
@@ -121,6 +126,8 @@ func (w *Writer) WriteGameRecords(records []GameRecord) error {
 			record.EndTime.Format(time.RFC3339),
 			record.Duration.String(),
 			strconv.Itoa(record.TotalMoves),
+			formatPlayers(record.Placements),
+			record.Map,
 		}
 		err = writer.Write(row)
 		if err != nil {
@@ -173,6 +180,15 @@ func (w *Writer) WriteMoveRecords(records []MoveRecord) error {
 	return nil
 }
 
+// formatPlayers joins player IDs into a single semicolon-separated field
+func formatPlayers(players []int) string {
+	fields := make([]string, len(players))
+	for i, player := range players {
+		fields[i] = strconv.Itoa(player)
+	}
+	return strings.Join(fields, ";")
+}
+
 func getFnName(fn any) string {
 	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
 	return path.Base(name)
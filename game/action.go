@@ -9,6 +9,10 @@ const (
 	ReinforceAction
 	ManeuverAction
 	PassAction
+	TradeCardsAction
+	ClaimAction  // Claim an unowned canton during the setup phase
+	OccupyAction // Advance troops into a conquered canton
+	DefendAction // Choose how many dice to defend a canton with
 )
 
 // Action represents an action taken by a player.
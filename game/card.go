@@ -1,5 +1,12 @@
 package game
 
+const (
+	DefaultWildCards         = 2 // Number of wild cards in the deck besides one card per canton
+	SetSize                  = 3 // Number of cards in a set traded in for troops
+	MandatoryTradeHandSize   = 5 // Hand size at which a player must trade in sets
+	EliminationTradeHandSize = 6 // Hand size at which a conqueror must trade in sets right after eliminating a player
+)
+
 type CardType int
 
 const (
@@ -10,6 +17,19 @@ const (
 )
 
 type RiskCard struct {
-	Type        CardType
-	TerritoryID int
+	Type        CardType `json:"type"`
+	TerritoryID int      `json:"territory"` // -1 for wild cards
+}
+
+// IsSet checks whether cards form a set that can be traded in for troops:
+// three of a kind, one of each kind, or any two plus a wild
+func IsSet(cards [SetSize]RiskCard) bool {
+	counts := map[CardType]int{}
+	for _, card := range cards {
+		counts[card.Type]++
+	}
+	if counts[Wild] > 0 {
+		return true
+	}
+	return len(counts) == 1 || len(counts) == SetSize
 }
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCards(t *testing.T) {
+	t.Run("deals one card per canton plus wild cards", func(t *testing.T) {
+		m := CreateMap()
+		gs := NewGameState(m, NewStandardRules())
+
+		require.Len(t, gs.Cards, len(m.Cantons)+DefaultWildCards)
+		territories := make(map[int]bool)
+		wilds := 0
+		for _, card := range gs.Cards {
+			if card.Type == Wild {
+				wilds++
+				continue
+			}
+			require.False(t, territories[card.TerritoryID], "Canton %d should have a single card", card.TerritoryID)
+			territories[card.TerritoryID] = true
+		}
+		require.Len(t, territories, len(m.Cantons), "Every canton should have a card")
+		require.Equal(t, DefaultWildCards, wilds)
+	})
+
+	t.Run("configures the number of wild cards", func(t *testing.T) {
+		m := CreateMap()
+		gs := NewGameState(m, NewStandardRules(), WithWildCards(0))
+
+		require.Len(t, gs.Cards, len(m.Cantons))
+	})
+}
+
+func TestDrawCard(t *testing.T) {
+	t.Run("draws from the top of the deck", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewStandardRules())
+		top := gs.Cards[0]
+		size := len(gs.Cards)
+
+		card, ok := gs.DrawCard()
+
+		require.True(t, ok)
+		require.Equal(t, top, card)
+		require.Len(t, gs.Cards, size-1, "Card should leave the deck")
+	})
+
+	t.Run("reshuffles discarded cards into an empty deck", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewStandardRules())
+		gs.DiscardedCards = gs.Cards[:3]
+		gs.Cards = nil
+
+		_, ok := gs.DrawCard()
+
+		require.True(t, ok)
+		require.Len(t, gs.Cards, 2, "Discarded cards should form the new deck")
+		require.Empty(t, gs.DiscardedCards)
+	})
+
+	t.Run("fails without any cards left", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewStandardRules())
+		gs.Cards = nil
+		gs.DiscardedCards = nil
+
+		_, ok := gs.DrawCard()
+
+		require.False(t, ok)
+	})
+}
+
+func TestAwardCard(t *testing.T) {
+	t.Run("awards a card at the end of a turn with a conquest", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.Ownership[20] = 2 // Keep player 2 in the game after losing GE
+		deck := len(gs.Cards)
+
+		gs = gs.Play(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}).(*GameState)
+		gs = gs.Play(&GameMove{ActionType: PassAction}).(*GameState) // End attack phase
+		gs = gs.Play(&GameMove{ActionType: PassAction}).(*GameState) // End maneuver phase
+
+		require.Len(t, gs.PlayerHands[1], 1, "Conqueror should draw a card")
+		require.Len(t, gs.Cards, deck-1, "Card should leave the deck")
+		require.False(t, gs.ConqueredThisTurn, "Conquest should reset for the next turn")
+	})
+
+	t.Run("awards no card without a conquest", func(t *testing.T) {
+		gs := newEliminationState()
+
+		gs = gs.Play(&GameMove{ActionType: PassAction}).(*GameState) // End attack phase
+		gs = gs.Play(&GameMove{ActionType: PassAction}).(*GameState) // End maneuver phase
+
+		require.Empty(t, gs.PlayerHands[1])
+	})
+}
+
+func TestTerritoryBonus(t *testing.T) {
+	gs := NewGameState(CreateMap(), NewStandardRules())
+	owned := -1
+	for cantonID, owner := range gs.Ownership {
+		if owner == gs.CurrentPlayer {
+			owned = cantonID
+			break
+		}
+	}
+	gs.PlayerHands[gs.CurrentPlayer] = []RiskCard{{Type: Infantry, TerritoryID: owned}, {Type: Wild, TerritoryID: -1}, {Type: Wild, TerritoryID: -1}}
+	troops := gs.TroopCounts[owned]
+
+	got := gs.Play(&GameMove{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 1, 2}}).(*GameState)
+
+	require.Equal(t, troops+2, got.TroopCounts[owned], "Should place 2 extra troops on an owned canton in the set")
+}
@@ -0,0 +1,52 @@
+package game
+
+import "fmt"
+
+// Battle is an attack declared by the current player that awaits the dice of
+// the defender before being resolved
+type Battle struct {
+	From   int `json:"from"`
+	To     int `json:"to"`
+	Troops int `json:"troops"` // Troops committed to the attack
+}
+
+// maxDefendDice returns the most dice the defender of the pending battle can roll
+func (gs *GameState) maxDefendDice() int {
+	return min(gs.TroopCounts[gs.Battle.To], gs.Rules.MaxDefendTroops())
+}
+
+// defendMoves generates a move for each number of dice the defender of the
+// pending battle can roll
+func (gs *GameState) defendMoves(buf *buffers) {
+	for dice := 1; dice <= gs.maxDefendDice(); dice++ {
+		buf.moves = append(buf.moves, GameMove{
+			ActionType:   DefendAction,
+			FromCantonID: gs.Battle.From,
+			ToCantonID:   gs.Battle.To,
+			NumTroops:    dice,
+		})
+	}
+}
+
+// declareAttack checks an attack and hands the decision of how many dice to
+// roll over to the defender, the attack being resolved once it has chosen
+func (gs *GameState) declareAttack(attackerID, defenderID, numTroops int) error {
+	if err := gs.checkAttack(attackerID, defenderID, numTroops); err != nil {
+		return err
+	}
+	gs.setBattle(Battle{From: attackerID, To: defenderID, Troops: numTroops})
+	gs.setPhase(DefendPhase)
+	return nil
+}
+
+// defend resolves the pending battle with the defender rolling up to the given
+// number of dice, and hands the turn back to the attacker
+func (gs *GameState) defend(dice int) error {
+	if dice <= 0 || dice > gs.maxDefendDice() {
+		return fmt.Errorf("cannot defend: rolling %d of %d dice", dice, gs.maxDefendDice())
+	}
+	battle := gs.Battle
+	gs.setBattle(Battle{})
+	gs.setPhase(AttackPhase)
+	return gs.attack(battle.From, battle.To, battle.Troops, dice)
+}
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefenderDice(t *testing.T) {
+	defenderDiceRules := func() *StandardRules {
+		rules := NewStandardRules()
+		rules.DefenderDice = true
+		return rules
+	}
+	newDefenderDiceState := func() *GameState {
+		gs := newEliminationState()
+		gs.Rules = defenderDiceRules()
+		gs.TroopCounts[7] = 5
+		return gs
+	}
+	attack := &GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}
+	defend := func(dice int) Move {
+		return &GameMove{ActionType: DefendAction, FromCantonID: 22, ToCantonID: 7, NumTroops: dice}
+	}
+
+	t.Run("rolls as many dice as the defender can by default", func(t *testing.T) {
+		gs := newEliminationState()
+
+		got := gs.Play(attack).(*GameState)
+
+		require.Equal(t, AttackPhase, got.Phase)
+		require.Zero(t, got.Battle)
+		require.Equal(t, 1, got.Ownership[7])
+	})
+
+	t.Run("leaves the dice to the defender", func(t *testing.T) {
+		gs := newDefenderDiceState()
+
+		got := gs.Play(attack).(*GameState)
+
+		require.Equal(t, DefendPhase, got.Phase)
+		require.Equal(t, Battle{From: 22, To: 7}, got.Battle)
+		require.Equal(t, 1000, got.TroopCounts[22], "Should not roll before the defender chooses its dice")
+		require.Equal(t, 1, got.CurrentPlayer, "Should stay the attacker's turn")
+		require.Equal(t, 2, got.Decider())
+		require.Equal(t, playerName(2), got.Player(), "Should let the defender move")
+		require.Equal(t, []Move{defend(1), defend(2)}, got.LegalMoves())
+
+		got = got.Play(defend(1)).(*GameState)
+
+		require.Equal(t, AttackPhase, got.Phase, "Should resume the attack")
+		require.Zero(t, got.Battle)
+		require.Equal(t, 1, got.Decider())
+		require.Equal(t, 1, got.Ownership[7], "Should resolve the attack")
+	})
+
+	t.Run("rolls no dice when declaring an attack", func(t *testing.T) {
+		gs := newDefenderDiceState()
+
+		require.True(t, attack.IsStochastic(), "Should not tell without the rules")
+		require.False(t, gs.IsStochastic(attack))
+		require.True(t, newEliminationState().IsStochastic(attack), "Should roll when the defender cannot choose")
+		require.True(t, gs.Play(attack).(*GameState).IsStochastic(defend(1)))
+		require.False(t, gs.IsStochastic(&GameMove{ActionType: PassAction}))
+	})
+
+	t.Run("loses at most a troop per roll with a single die", func(t *testing.T) {
+		gs := newDefenderDiceState()
+		gs.Rules.(*StandardRules).Attack = SingleRollAttack
+
+		got := gs.Play(attack).Play(defend(1)).(*GameState)
+
+		lost := 1000 - got.TroopCounts[22] + 5 - got.TroopCounts[7]
+		require.Equal(t, 1, lost, "Should compare a single pair of dice")
+	})
+
+	t.Run("validates defenses", func(t *testing.T) {
+		got := newDefenderDiceState().Play(attack).(*GameState)
+
+		require.ErrorIs(t, got.ValidateMove(defend(0)), ErrInvalidDice)
+		require.ErrorIs(t, got.ValidateMove(defend(3)), ErrInvalidDice)
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: DefendAction, FromCantonID: 22, ToCantonID: 0, NumTroops: 1}), ErrNotAttacked)
+		require.ErrorIs(t, got.ValidateMove(attack), ErrWrongPhase)
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: PassAction}), ErrWrongPhase)
+		require.NoError(t, got.ValidateMove(defend(2)))
+
+		got.TroopCounts[7] = 1
+		require.ErrorIs(t, got.ValidateMove(defend(2)), ErrInvalidDice, "Should roll no more dice than troops")
+	})
+
+	t.Run("plays random games with defenses", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rng := rand.New(rand.NewSource(1))
+		gs := NewGameState(CreateMap(), defenderDiceRules(), WithPlayers(3), WithSeed(1))
+		var state State = gs
+		r := NewGameState(CreateMap(), defenderDiceRules(), WithPlayers(3), WithSeed(1)).Rollout()
+		start := r.State().Hash()
+		played, defenses := 0, 0
+		for ; played < 3000 && state.Winner() == ""; played++ {
+			moves := state.LegalMoves()
+			for _, move := range moves {
+				require.NoError(t, state.(*GameState).ValidateMove(move))
+			}
+			if state.(*GameState).Phase == DefendPhase {
+				require.NotEqual(t, state.(*GameState).CurrentPlayer, state.(*GameState).Decider())
+				defenses++
+			}
+			index := rng.Intn(len(moves))
+			state = state.Play(moves[index])
+			r.Apply(r.LegalMoves()[index])
+			require.Equal(t, state.Hash(), r.State().Hash(), "Should defend the same way in place")
+		}
+		require.Positive(t, defenses, "Should let defenders choose their dice")
+
+		for i := 0; i < played; i++ {
+			r.Undo()
+		}
+		require.Equal(t, start, r.State().Hash(), "Should undo the defenses")
+	})
+
+	t.Run("encodes the pending battle", func(t *testing.T) {
+		gs := newDefenderDiceState().Play(attack).(*GameState)
+		gs.rng = nil
+
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(data, &got))
+		got.owned, got.copied = gs.owned, gs.copied // Whether memory is shared with other states is not encoded
+
+		require.Equal(t, *gs, got)
+		require.Equal(t, gs.Hash(), got.Hash())
+		require.Equal(t, gs.LegalMoves(), got.LegalMoves())
+	})
+}
@@ -0,0 +1,387 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// StateVersion is the version of the JSON encoding of GameState. It is bumped
+// whenever the encoding changes in a way older decoders cannot read. Version 2
+// added the setup, occupy and defend phases, pending occupations and battles,
+// and the maneuvers and fortified cantons of the turn.
+const StateVersion = 2
+
+// stateJSON is the JSON encoding of GameState. The map is referenced by name
+// rather than embedded, and the rules and last move carry their own type.
+type stateJSON struct {
+	Version           int             `json:"version"`
+	Map               string          `json:"map"`
+	Rules             json.RawMessage `json:"rules"`
+	TroopCounts       []int           `json:"troopCounts"`
+	Ownership         []int           `json:"ownership"`
+	NumPlayers        int             `json:"numPlayers"`
+	CurrentPlayer     int             `json:"currentPlayer"`
+	LastMove          *GameMove       `json:"lastMove"`
+	Phase             Phase           `json:"phase"`
+	PlayerTroops      map[int]int     `json:"playerTroops"`
+	TroopsToPlace     int             `json:"troopsToPlace"`
+	Cards             []RiskCard      `json:"cards"`
+	DiscardedCards    []RiskCard      `json:"discardedCards"`
+	PlayerHands       [][]RiskCard    `json:"playerHands"`
+	Exchanges         int             `json:"exchanges"`
+	ConqueredThisTurn bool            `json:"conqueredThisTurn"`
+	Eliminated        []int           `json:"eliminated"`
+	Won               string          `json:"won"`
+	Occupation        *Occupation     `json:"occupation,omitempty"`
+	Battle            *Battle         `json:"battle,omitempty"`
+	Maneuvers         int             `json:"maneuvers,omitempty"`
+	Fortified         []int           `json:"fortified,omitempty"`
+}
+
+func (gs GameState) MarshalJSON() ([]byte, error) {
+	if gs.Map == nil || gs.Map.Name == "" {
+		return nil, fmt.Errorf("cannot encode a state without a named map")
+	}
+	if gs.Rules == nil {
+		return nil, fmt.Errorf("cannot encode a state without rules")
+	}
+	rules, err := json.Marshal(gs.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rules: %w", err)
+	}
+
+	var lastMove *GameMove
+	switch move := gs.LastMove.(type) {
+	case nil:
+	case *GameMove:
+		lastMove = move
+	case GameMove:
+		lastMove = &move
+	default:
+		return nil, fmt.Errorf("cannot encode last move of type %T", gs.LastMove)
+	}
+
+	var occupation *Occupation
+	if gs.Occupation != (Occupation{}) {
+		occupation = &gs.Occupation
+	}
+	var battle *Battle
+	if gs.Battle != (Battle{}) {
+		battle = &gs.Battle
+	}
+
+	return json.Marshal(stateJSON{
+		Version:           StateVersion,
+		Map:               gs.Map.Name,
+		Rules:             rules,
+		TroopCounts:       gs.TroopCounts,
+		Ownership:         gs.Ownership,
+		NumPlayers:        gs.NumPlayers,
+		CurrentPlayer:     gs.CurrentPlayer,
+		LastMove:          lastMove,
+		Phase:             gs.Phase,
+		PlayerTroops:      gs.PlayerTroops,
+		TroopsToPlace:     gs.TroopsToPlace,
+		Cards:             gs.Cards,
+		DiscardedCards:    gs.DiscardedCards,
+		PlayerHands:       gs.PlayerHands,
+		Exchanges:         gs.Exchanges,
+		ConqueredThisTurn: gs.ConqueredThisTurn,
+		Eliminated:        gs.Eliminated,
+		Won:               gs.Won,
+		Occupation:        occupation,
+		Battle:            battle,
+		Maneuvers:         gs.Maneuvers,
+		Fortified:         gs.Fortified,
+	})
+}
+
+func (gs *GameState) UnmarshalJSON(data []byte) error {
+	var s stateJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s.Version {
+	case StateVersion:
+	case 1:
+		if err := s.checkVersion1(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported state version %d, expected %d", s.Version, StateVersion)
+	}
+
+	m, err := ResolveMap(s.Map)
+	if err != nil {
+		return err
+	}
+	if len(s.TroopCounts) != len(m.Cantons) || len(s.Ownership) != len(m.Cantons) {
+		return fmt.Errorf("state does not fit the %s map of %d cantons", m.Name, len(m.Cantons))
+	}
+	if s.NumPlayers < MinPlayers || s.NumPlayers > MaxPlayers || len(s.PlayerHands) != s.NumPlayers+1 {
+		return fmt.Errorf("invalid number of players %d with %d hands", s.NumPlayers, len(s.PlayerHands))
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+	rules, err := UnmarshalRules(s.Rules)
+	if err != nil {
+		return err
+	}
+
+	*gs = GameState{
+		Map:               m,
+		TroopCounts:       s.TroopCounts,
+		Ownership:         s.Ownership,
+		Rules:             rules,
+		NumPlayers:        s.NumPlayers,
+		CurrentPlayer:     s.CurrentPlayer,
+		Phase:             s.Phase,
+		PlayerTroops:      s.PlayerTroops,
+		TroopsToPlace:     s.TroopsToPlace,
+		Cards:             s.Cards,
+		DiscardedCards:    s.DiscardedCards,
+		PlayerHands:       s.PlayerHands,
+		Exchanges:         s.Exchanges,
+		ConqueredThisTurn: s.ConqueredThisTurn,
+		Eliminated:        s.Eliminated,
+		Won:               s.Won,
+		Maneuvers:         s.Maneuvers,
+		Fortified:         s.Fortified,
+		owned:             allComponents,
+		copied:            new(atomic.Bool),
+	}
+	if s.LastMove != nil {
+		gs.LastMove = s.LastMove
+	}
+	if s.Occupation != nil {
+		gs.Occupation = *s.Occupation
+	}
+	if s.Battle != nil {
+		gs.Battle = *s.Battle
+	}
+	gs.Rehash()
+	return nil
+}
+
+// checkVersion1 checks that a version 1 state uses none of the features added
+// since, whose fields then decode to their zero values as in a version 2 state
+func (s *stateJSON) checkVersion1() error {
+	if s.Phase > EndPhase {
+		return fmt.Errorf("invalid phase %d in a version 1 state", s.Phase)
+	}
+	if s.Occupation != nil || s.Battle != nil || s.Maneuvers != 0 || len(s.Fortified) != 0 {
+		return fmt.Errorf("version 1 state has fields of version %d", StateVersion)
+	}
+	return nil
+}
+
+// validate range checks the players, cantons and enums a decoded state refers
+// to, which moves and hashing would otherwise index out of range. The map size
+// and number of players must have been checked first.
+func (s *stateJSON) validate() error {
+	numCantons := len(s.TroopCounts)
+	isPlayer := func(playerID int) bool { return playerID >= 1 && playerID <= s.NumPlayers }
+	isCanton := func(cantonID int) bool { return cantonID >= 0 && cantonID < numCantons }
+
+	if !isPlayer(s.CurrentPlayer) {
+		return fmt.Errorf("invalid current player %d", s.CurrentPlayer)
+	}
+	if s.Phase < ReinforcementPhase || s.Phase > DefendPhase {
+		return fmt.Errorf("invalid phase %d", s.Phase)
+	}
+	for cantonID, owner := range s.Ownership {
+		if owner != -1 && !isPlayer(owner) {
+			return fmt.Errorf("invalid owner %d of canton %d", owner, cantonID)
+		}
+		if s.TroopCounts[cantonID] < 0 {
+			return fmt.Errorf("invalid troop count %d of canton %d", s.TroopCounts[cantonID], cantonID)
+		}
+	}
+	for playerID := range s.PlayerTroops {
+		if !isPlayer(playerID) {
+			return fmt.Errorf("invalid player %d with troops to place", playerID)
+		}
+	}
+	for _, cards := range append([][]RiskCard{s.Cards, s.DiscardedCards}, s.PlayerHands...) {
+		for _, card := range cards {
+			if card.Type < Infantry || card.Type > Wild || (card.TerritoryID != -1 && !isCanton(card.TerritoryID)) {
+				return fmt.Errorf("invalid card %+v", card)
+			}
+		}
+	}
+	if s.Occupation != nil && (!isCanton(s.Occupation.From) || !isCanton(s.Occupation.To)) {
+		return fmt.Errorf("invalid occupation %+v", *s.Occupation)
+	}
+	if s.Battle != nil && (!isCanton(s.Battle.From) || !isCanton(s.Battle.To) || s.Battle.Troops < 0) {
+		return fmt.Errorf("invalid battle %+v", *s.Battle)
+	}
+	for _, cantonID := range s.Fortified {
+		if !isCanton(cantonID) {
+			return fmt.Errorf("invalid fortified canton %d", cantonID)
+		}
+	}
+	if len(s.Eliminated) >= s.NumPlayers {
+		return fmt.Errorf("%d of %d players eliminated", len(s.Eliminated), s.NumPlayers)
+	}
+	for i, playerID := range s.Eliminated {
+		if !isPlayer(playerID) || slices.Contains(s.Eliminated[:i], playerID) {
+			return fmt.Errorf("invalid eliminated player %d", playerID)
+		}
+	}
+	return nil
+}
+
+// actionNames are the move types used to discriminate moves in JSON
+var actionNames = map[ActionType]string{
+	MoveAction:       "move",
+	AttackAction:     "attack",
+	ReinforceAction:  "reinforce",
+	ManeuverAction:   "maneuver",
+	PassAction:       "pass",
+	TradeCardsAction: "trade",
+	ClaimAction:      "claim",
+	OccupyAction:     "occupy",
+	DefendAction:     "defend",
+}
+
+// moveJSON is the JSON encoding of GameMove, holding only the fields used by its type
+type moveJSON struct {
+	Type   string        `json:"type"`
+	From   *int          `json:"from,omitempty"`
+	To     *int          `json:"to,omitempty"`
+	Troops *int          `json:"troops,omitempty"`
+	Cards  *[SetSize]int `json:"cards,omitempty"`
+}
+
+func (gm GameMove) MarshalJSON() ([]byte, error) {
+	name, ok := actionNames[gm.ActionType]
+	if !ok {
+		return nil, fmt.Errorf("cannot encode move with unknown action type %d", gm.ActionType)
+	}
+	m := moveJSON{Type: name}
+	switch gm.ActionType {
+	case ReinforceAction:
+		m.To, m.Troops = &gm.ToCantonID, &gm.NumTroops
+	case AttackAction:
+		m.From, m.To, m.Troops = &gm.FromCantonID, &gm.ToCantonID, &gm.NumTroops
+	case MoveAction, ManeuverAction, OccupyAction, DefendAction:
+		m.From, m.To, m.Troops = &gm.FromCantonID, &gm.ToCantonID, &gm.NumTroops
+	case TradeCardsAction:
+		m.Cards = &gm.CardIndices
+	case ClaimAction:
+		m.To = &gm.ToCantonID
+	}
+	return json.Marshal(m)
+}
+
+func (gm *GameMove) UnmarshalJSON(data []byte) error {
+	var m moveJSON
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	actionType := ActionType(-1)
+	for action, name := range actionNames {
+		if name == m.Type {
+			actionType = action
+		}
+	}
+
+	var missing bool
+	switch actionType {
+	case ReinforceAction:
+		missing = m.To == nil || m.Troops == nil
+	case AttackAction: // Troops committed were not encoded before attacks could stop early
+		missing = m.From == nil || m.To == nil
+	case MoveAction, ManeuverAction, OccupyAction, DefendAction:
+		missing = m.From == nil || m.To == nil || m.Troops == nil
+	case TradeCardsAction:
+		missing = m.Cards == nil
+	case ClaimAction:
+		missing = m.To == nil
+	case PassAction:
+	default:
+		return fmt.Errorf("unknown move type %q", m.Type)
+	}
+	if missing {
+		return fmt.Errorf("%s move is missing a field", m.Type)
+	}
+
+	*gm = GameMove{ActionType: actionType}
+	if m.From != nil {
+		gm.FromCantonID = *m.From
+	}
+	if m.To != nil {
+		gm.ToCantonID = *m.To
+	}
+	if m.Troops != nil {
+		gm.NumTroops = *m.Troops
+	}
+	if m.Cards != nil {
+		gm.CardIndices = *m.Cards
+	}
+	return nil
+}
+
+// decodableRules are rules that can check their fields once decoded from JSON
+type decodableRules interface {
+	Rules
+	validate() error
+}
+
+// rulesTypes maps the type names of rules in JSON to constructors of empty rules to decode into
+var rulesTypes = map[string]func() decodableRules{
+	standardRulesType:        func() decodableRules { return &StandardRules{} },
+	fixedCardRulesType:       func() decodableRules { return &FixedCardRules{} },
+	progressiveCardRulesType: func() decodableRules { return &ProgressiveCardRules{} },
+}
+
+// UnmarshalRules decodes rules of any known type from JSON
+func UnmarshalRules(data []byte) (Rules, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode rules: %w", err)
+	}
+	create, ok := rulesTypes[header.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown rules type %q", header.Type)
+	}
+	rules := create()
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to decode %s rules: %w", header.Type, err)
+	}
+	if err := rules.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s rules: %w", header.Type, err)
+	}
+	return rules, nil
+}
+
+var (
+	registeredMapsMu sync.RWMutex
+	registeredMaps   = make(map[string]*Map)
+)
+
+// RegisterMap makes a map that is not built in, such as a loaded or generated
+// map, resolvable by name when decoding states played on it
+func RegisterMap(m *Map) {
+	registeredMapsMu.Lock()
+	defer registeredMapsMu.Unlock()
+
+	registeredMaps[m.Name] = m
+}
+
+// ResolveMap finds a registered or built-in map by name
+func ResolveMap(name string) (*Map, error) {
+	registeredMapsMu.RLock()
+	m, ok := registeredMaps[name]
+	registeredMapsMu.RUnlock()
+	if ok {
+		return m, nil
+	}
+	return BuiltinMap(name)
+}
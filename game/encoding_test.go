@@ -0,0 +1,201 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// playedState plays a few random moves so the state has a last move, cards and a deck in use
+func playedState(t *testing.T, m *Map) *GameState {
+	t.Helper()
+	var state State = NewGameState(m, NewStandardRules(), WithPlayers(3), WithSeed(3))
+	for i := 0; i < 200 && state.Winner() == ""; i++ {
+		moves := state.LegalMoves()
+		state = state.Play(moves[i%len(moves)])
+	}
+	gs := state.(*GameState)
+	gs.rng = nil // The source of randomness is not part of the encoding
+	return gs
+}
+
+func TestStateJSON(t *testing.T) {
+	t.Run("round-trips a game in progress", func(t *testing.T) {
+		gs := playedState(t, CreateMap())
+		gs.PlayerHands[1] = append(gs.PlayerHands[1], RiskCard{Type: Wild, TerritoryID: -1})
+		gs.Eliminated = []int{2}
+		gs.Rehash()
+
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(data, &got))
+		got.owned, got.copied = gs.owned, gs.copied // Whether memory is shared with other states is not encoded
+
+		require.Equal(t, *gs, got)
+		require.Equal(t, gs.Hash(), got.Hash())
+		require.Equal(t, gs.LegalMoves(), got.LegalMoves())
+	})
+
+	t.Run("references the map by name", func(t *testing.T) {
+		data, err := json.Marshal(NewGameState(CreateClassicMap(), NewStandardRules()))
+		require.NoError(t, err)
+
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(data, &fields))
+		require.Equal(t, "classic", fields["map"])
+		require.Equal(t, float64(StateVersion), fields["version"])
+		require.Equal(t, map[string]any{"type": "standard", "maxAttackDice": 3.0, "maxDefendDice": 2.0}, fields["rules"])
+	})
+
+	t.Run("resolves registered maps", func(t *testing.T) {
+		m, err := GenerateMap(GeneratorConfig{Territories: 12, Regions: 3, AverageDegree: 3, Seed: 5})
+		require.NoError(t, err)
+		gs := NewGameState(m, NewStandardRules())
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+
+		var got GameState
+		require.Error(t, json.Unmarshal(data, &got), "Should not resolve an unregistered map")
+
+		RegisterMap(m)
+		require.NoError(t, json.Unmarshal(data, &got))
+		require.Same(t, m, got.Map)
+	})
+
+	t.Run("rejects invalid states", func(t *testing.T) {
+		data, err := json.Marshal(NewGameState(CreateMap(), NewStandardRules()))
+		require.NoError(t, err)
+		valid := string(data)
+
+		cases := map[string]string{
+			"unsupported version": strings.Replace(valid, `"version":2`, `"version":99`, 1),
+			"unknown map":         strings.Replace(valid, `"map":"switzerland"`, `"map":"atlantis"`, 1),
+			"map mismatch":        strings.Replace(valid, `"map":"switzerland"`, `"map":"classic"`, 1),
+			"unknown rules":       strings.Replace(valid, `"type":"standard"`, `"type":"house"`, 1),
+			"no attack dice":      strings.Replace(valid, `"maxAttackDice":3`, `"maxAttackDice":0`, 1),
+			"no defend dice":      strings.Replace(valid, `"maxDefendDice":2`, `"maxDefendDice":-1`, 1),
+			"attack granularity":  strings.Replace(valid, `"maxDefendDice":2`, `"maxDefendDice":2,"attack":3`, 1),
+			"negative attack":     strings.Replace(valid, `"maxDefendDice":2`, `"maxDefendDice":2,"attack":-1`, 1),
+			"fortify rule":        strings.Replace(valid, `"maxDefendDice":2`, `"maxDefendDice":2,"fortify":3`, 1),
+			"negative fortify":    strings.Replace(valid, `"maxDefendDice":2`, `"maxDefendDice":2,"fortify":-1`, 1),
+		}
+		for name, data := range cases {
+			var got GameState
+			require.Error(t, json.Unmarshal([]byte(data), &got), name)
+		}
+	})
+
+	t.Run("decodes version 1 states", func(t *testing.T) {
+		gs := playedState(t, CreateMap())
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(data, &fields))
+		fields["version"] = 1
+		for _, field := range []string{"occupation", "battle", "maneuvers", "fortified"} {
+			delete(fields, field)
+		}
+		gs.Occupation, gs.Battle, gs.Maneuvers, gs.Fortified = Occupation{}, Battle{}, 0, nil
+		gs.Rehash()
+
+		v1, err := json.Marshal(fields)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(v1, &got))
+		got.owned, got.copied = gs.owned, gs.copied
+		require.Equal(t, *gs, got)
+
+		fields["phase"] = int(OccupyPhase)
+		v1, err = json.Marshal(fields)
+		require.NoError(t, err)
+		require.Error(t, json.Unmarshal(v1, &got), "Should reject phases added in version 2")
+
+		fields["phase"] = int(AttackPhase)
+		fields["maneuvers"] = 1
+		v1, err = json.Marshal(fields)
+		require.NoError(t, err)
+		require.Error(t, json.Unmarshal(v1, &got), "Should reject fields added in version 2")
+	})
+
+	t.Run("rejects players, cantons and enums out of range", func(t *testing.T) {
+		data, err := json.Marshal(playedState(t, CreateMap()))
+		require.NoError(t, err)
+
+		cases := map[string]func(fields map[string]any){
+			"current player":      func(fields map[string]any) { fields["currentPlayer"] = 4 },
+			"no current player":   func(fields map[string]any) { fields["currentPlayer"] = 0 },
+			"phase":               func(fields map[string]any) { fields["phase"] = int(DefendPhase) + 1 },
+			"negative phase":      func(fields map[string]any) { fields["phase"] = -1 },
+			"owner":               func(fields map[string]any) { fields["ownership"].([]any)[3] = 4 },
+			"unowned by zero":     func(fields map[string]any) { fields["ownership"].([]any)[3] = 0 },
+			"troop count":         func(fields map[string]any) { fields["troopCounts"].([]any)[3] = -1 },
+			"card type":           func(fields map[string]any) { fields["cards"] = []any{map[string]any{"type": 4, "territory": 0}} },
+			"card territory":      func(fields map[string]any) { fields["cards"] = []any{map[string]any{"type": 0, "territory": 26}} },
+			"occupation from":     func(fields map[string]any) { fields["occupation"] = map[string]any{"from": -1, "to": 3} },
+			"occupation to":       func(fields map[string]any) { fields["occupation"] = map[string]any{"from": 3, "to": 26} },
+			"battle from":         func(fields map[string]any) { fields["battle"] = map[string]any{"from": 26, "to": 3, "troops": 1} },
+			"battle to":           func(fields map[string]any) { fields["battle"] = map[string]any{"from": 3, "to": -1, "troops": 1} },
+			"battle troops":       func(fields map[string]any) { fields["battle"] = map[string]any{"from": 3, "to": 4, "troops": -1} },
+			"fortified":           func(fields map[string]any) { fields["fortified"] = []any{3, 26} },
+			"eliminated":          func(fields map[string]any) { fields["eliminated"] = []any{4} },
+			"eliminated twice":    func(fields map[string]any) { fields["eliminated"] = []any{2, 2} },
+			"everyone eliminated": func(fields map[string]any) { fields["eliminated"] = []any{1, 2, 3} },
+		}
+		for name, corrupt := range cases {
+			var fields map[string]any
+			require.NoError(t, json.Unmarshal(data, &fields))
+			corrupt(fields)
+			corrupted, err := json.Marshal(fields)
+			require.NoError(t, err)
+
+			var got GameState
+			require.Error(t, json.Unmarshal(corrupted, &got), name)
+		}
+	})
+}
+
+func TestMoveJSON(t *testing.T) {
+	t.Run("round-trips every move type", func(t *testing.T) {
+		moves := []GameMove{
+			{ActionType: ReinforceAction, ToCantonID: 0, NumTroops: 3},
+			{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7, NumTroops: 999},
+			{ActionType: ManeuverAction, FromCantonID: 1, ToCantonID: 2, NumTroops: 5},
+			{ActionType: PassAction},
+			{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 2, 4}},
+			{ActionType: ClaimAction, ToCantonID: 7},
+			{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 7, NumTroops: 4},
+			{ActionType: DefendAction, FromCantonID: 22, ToCantonID: 7, NumTroops: 2},
+		}
+		for _, move := range moves {
+			data, err := json.Marshal(&move)
+			require.NoError(t, err)
+			var got GameMove
+			require.NoError(t, json.Unmarshal(data, &got))
+			require.Equal(t, move, got)
+		}
+	})
+
+	t.Run("discriminates moves by type", func(t *testing.T) {
+		data, err := json.Marshal(&GameMove{ActionType: ReinforceAction, FromCantonID: 5, ToCantonID: 1, NumTroops: 9})
+		require.NoError(t, err)
+
+		require.JSONEq(t, `{"type":"reinforce","to":1,"troops":9}`, string(data), "Should only encode the fields of the move type")
+	})
+
+	t.Run("decodes attacks encoded without the troops committed", func(t *testing.T) {
+		var got GameMove
+		require.NoError(t, json.Unmarshal([]byte(`{"type":"attack","from":22,"to":7}`), &got))
+
+		require.Equal(t, GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}, got)
+	})
+
+	t.Run("rejects invalid moves", func(t *testing.T) {
+		for _, data := range []string{`{"type":"jump"}`, `{"type":"attack","from":1}`, `{"type":"trade"}`} {
+			var got GameMove
+			require.Error(t, json.Unmarshal([]byte(data), &got), data)
+		}
+	})
+}
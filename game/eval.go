@@ -65,10 +65,8 @@ func (gs *GameState) calculateResourceScores() (territoryScore, troopScore float
 		}
 	}
 
-	current := gs.CurrentPlayer
-	opponent := gs.NextPlayer()
-	territoryScore = normalize(territories[current], territories[opponent])
-	troopScore = normalize(troops[current], troops[opponent])
+	territoryScore = gs.relativeScore(territories)
+	troopScore = gs.relativeScore(troops)
 	return territoryScore, troopScore
 }
 
@@ -83,16 +81,14 @@ func (gs *GameState) calculateBonusScore() float64 {
 		}
 	}
 
-	return normalize(regionBonus[gs.CurrentPlayer], regionBonus[gs.NextPlayer()])
+	return gs.relativeScore(regionBonus)
 }
 
 func (gs *GameState) calculateConnectivityScore() float64 {
-	current := gs.CurrentPlayer
-	opponent := gs.NextPlayer()
 	connectivity := make(map[int]float64)
 
 	// Build graph of controlled territories
-	for player := range []int{current, opponent} {
+	for player := 1; player <= gs.NumPlayers; player++ {
 		graph := make(map[int][]int)
 		for canton, owner := range gs.Ownership {
 			if owner == player {
@@ -122,17 +118,15 @@ func (gs *GameState) calculateConnectivityScore() float64 {
 		connectivity[player] = float64(maxComponent)
 	}
 
-	return normalize(connectivity[current], connectivity[opponent])
+	return gs.relativeScore(connectivity)
 }
 
 func (gs *GameState) calculateBorderScore() float64 {
-	current := gs.CurrentPlayer
-	opponent := gs.NextPlayer()
 	borderStrength := make(map[int]float64) // By player
 
 	// Calculate border strength for each player
 	for canton, owner := range gs.Ownership {
-		if owner != current && owner != opponent {
+		if owner <= 0 { // Skip unowned
 			continue
 		}
 
@@ -157,7 +151,7 @@ func (gs *GameState) calculateBorderScore() float64 {
 		}
 	}
 
-	return normalize(borderStrength[current], borderStrength[opponent])
+	return gs.relativeScore(borderStrength)
 }
 
 func getRegionOwner(region Region, ownerByCanton []int) int {
@@ -173,6 +167,21 @@ func getRegionOwner(region Region, ownerByCanton []int) int {
 	return owner
 }
 
+// relativeScore normalizes the value of the player to move relative to the
+// average value of its opponents to a score between -1 and 1
+func (gs *GameState) relativeScore(valueByPlayer map[int]float64) float64 {
+	player := gs.Decider()
+	opponents := gs.opponents(player)
+	opponentValue := 0.0
+	for _, opponent := range opponents {
+		opponentValue += valueByPlayer[opponent]
+	}
+	if len(opponents) > 0 {
+		opponentValue /= float64(len(opponents))
+	}
+	return normalize(valueByPlayer[player], opponentValue)
+}
+
 // normalize normalizes value relative to otherValue to a score between -1 and 1
 func normalize(value float64, otherValue float64) float64 {
 	total := value + otherValue
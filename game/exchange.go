@@ -0,0 +1,96 @@
+package game
+
+import "encoding/json"
+
+const (
+	fixedCardRulesType       = "fixedCards"       // Type of FixedCardRules in JSON
+	progressiveCardRulesType = "progressiveCards" // Type of ProgressiveCardRules in JSON
+)
+
+// FixedCardRules are standard rules except that sets of cards are worth a fixed
+// number of armies depending on their kinds, however many sets were traded in
+type FixedCardRules struct {
+	StandardRules
+	KindArmies  [Wild]int `json:"kindArmies"`  // Armies for three of a kind per kind, wilds standing for any kind
+	MixedArmies int       `json:"mixedArmies"` // Armies for one of each kind
+}
+
+func NewFixedCardRules() *FixedCardRules {
+	return &FixedCardRules{
+		StandardRules: *NewStandardRules(),
+		KindArmies:    [Wild]int{Infantry: 4, Cavalry: 6, Artillery: 8},
+		MixedArmies:   10,
+	}
+}
+
+// MarshalJSON tags the rules with their type so they can be decoded into the Rules interface
+func (fr *FixedCardRules) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*standardFields
+		KindArmies  [Wild]int `json:"kindArmies"`
+		MixedArmies int       `json:"mixedArmies"`
+	}{
+		Type:           fixedCardRulesType,
+		standardFields: (*standardFields)(&fr.StandardRules),
+		KindArmies:     fr.KindArmies,
+		MixedArmies:    fr.MixedArmies,
+	})
+}
+
+// ExchangeArmies awards the armies of the most valuable set the cards can form,
+// wilds standing for whichever kinds are missing as IsSet takes them: a single
+// card with two wilds forms three of its kind or one of each kind
+func (fr *FixedCardRules) ExchangeArmies(exchange int, set [SetSize]RiskCard) int {
+	var counts [Wild + 1]int
+	for _, card := range set {
+		counts[card.Type]++
+	}
+	armies, mixed := 0, true
+	for kind := Infantry; kind < Wild; kind++ {
+		if counts[kind]+counts[Wild] == SetSize {
+			armies = max(armies, fr.KindArmies[kind])
+		}
+		mixed = mixed && counts[kind] <= 1
+	}
+	if mixed {
+		armies = max(armies, fr.MixedArmies)
+	}
+	return armies
+}
+
+// ProgressiveCardRules are standard rules except that each set of cards traded
+// in is worth a constant number of armies more than the previous one, rather
+// than the standard schedule speeding up after the fifth set
+type ProgressiveCardRules struct {
+	StandardRules
+	FirstArmies int `json:"firstArmies"` // Armies for the first set traded in
+	StepArmies  int `json:"stepArmies"`  // Armies each set is worth more than the previous one
+}
+
+func NewProgressiveCardRules() *ProgressiveCardRules {
+	return &ProgressiveCardRules{
+		StandardRules: *NewStandardRules(),
+		FirstArmies:   4,
+		StepArmies:    2,
+	}
+}
+
+// MarshalJSON tags the rules with their type so they can be decoded into the Rules interface
+func (pr *ProgressiveCardRules) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*standardFields
+		FirstArmies int `json:"firstArmies"`
+		StepArmies  int `json:"stepArmies"`
+	}{
+		Type:           progressiveCardRulesType,
+		standardFields: (*standardFields)(&pr.StandardRules),
+		FirstArmies:    pr.FirstArmies,
+		StepArmies:     pr.StepArmies,
+	})
+}
+
+func (pr *ProgressiveCardRules) ExchangeArmies(exchange int, set [SetSize]RiskCard) int {
+	return pr.FirstArmies + pr.StepArmies*(exchange-1)
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardRules(t *testing.T) {
+	infantry := RiskCard{Type: Infantry, TerritoryID: 0}
+	cavalry := RiskCard{Type: Cavalry, TerritoryID: 1}
+	artillery := RiskCard{Type: Artillery, TerritoryID: 2}
+	wild := RiskCard{Type: Wild, TerritoryID: -1}
+	mixed := [SetSize]RiskCard{infantry, cavalry, artillery}
+
+	t.Run("escalates the armies of standard exchanges", func(t *testing.T) {
+		rules := NewStandardRules()
+
+		var got []int
+		for exchange := 1; exchange <= 8; exchange++ {
+			got = append(got, rules.ExchangeArmies(exchange, mixed))
+		}
+
+		require.Equal(t, []int{4, 6, 8, 10, 12, 15, 20, 25}, got)
+	})
+
+	t.Run("values sets by their kinds with fixed cards", func(t *testing.T) {
+		rules := NewFixedCardRules()
+
+		require.Equal(t, 4, rules.ExchangeArmies(1, [SetSize]RiskCard{infantry, infantry, infantry}))
+		require.Equal(t, 6, rules.ExchangeArmies(1, [SetSize]RiskCard{cavalry, wild, cavalry}), "Wild should complete three of a kind")
+		require.Equal(t, 8, rules.ExchangeArmies(7, [SetSize]RiskCard{artillery, artillery, artillery}), "Should not depend on the exchange")
+		require.Equal(t, 10, rules.ExchangeArmies(1, mixed))
+		require.Equal(t, 10, rules.ExchangeArmies(1, [SetSize]RiskCard{wild, cavalry, wild}), "Wilds should complete one of each kind")
+		require.Equal(t, 10, rules.ExchangeArmies(1, [SetSize]RiskCard{wild, wild, wild}))
+	})
+
+	t.Run("values wilds as the kinds making the most valuable set with fixed cards", func(t *testing.T) {
+		rules := NewFixedCardRules()
+		rules.KindArmies[Infantry] = 12
+
+		require.Equal(t, 12, rules.ExchangeArmies(1, [SetSize]RiskCard{infantry, wild, wild}), "Wilds should complete three of a kind")
+		require.Equal(t, 10, rules.ExchangeArmies(1, [SetSize]RiskCard{cavalry, wild, wild}), "Wilds should complete one of each kind")
+		require.Equal(t, 10, rules.ExchangeArmies(1, [SetSize]RiskCard{cavalry, artillery, wild}), "Wild should only complete one of each kind")
+		require.Equal(t, 12, rules.ExchangeArmies(1, [SetSize]RiskCard{wild, wild, wild}))
+	})
+
+	t.Run("adds the same armies with each progressive exchange", func(t *testing.T) {
+		rules := NewProgressiveCardRules()
+		rules.FirstArmies, rules.StepArmies = 5, 3
+
+		var got []int
+		for exchange := 1; exchange <= 8; exchange++ {
+			got = append(got, rules.ExchangeArmies(exchange, mixed))
+		}
+
+		require.Equal(t, []int{5, 8, 11, 14, 17, 20, 23, 26}, got)
+	})
+
+	t.Run("trades in cards for the armies of the rules", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewFixedCardRules())
+		gs.PlayerHands[gs.CurrentPlayer] = []RiskCard{cavalry, wild, infantry}
+		troops := gs.TroopsToPlace
+
+		got := gs.Play(&GameMove{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 1, 2}}).(*GameState)
+
+		require.Equal(t, troops+10, got.TroopsToPlace, "Should award the armies of one of each kind")
+	})
+
+	t.Run("round-trips every rules type through JSON", func(t *testing.T) {
+		standard := NewStandardRules()
+		standard.Maneuvers = -1
+		fixed := NewFixedCardRules()
+		fixed.MaxAttackDice = 2
+		progressive := NewProgressiveCardRules()
+		progressive.StepArmies = 5
+
+		for _, rules := range []Rules{standard, fixed, progressive} {
+			data, err := json.Marshal(rules)
+			require.NoError(t, err)
+
+			got, err := UnmarshalRules(data)
+			require.NoError(t, err)
+			require.Equal(t, rules, got)
+		}
+	})
+}
+
+func TestStandardRules(t *testing.T) {
+	rules := NewStandardRules()
+
+	t.Run("awards a troop per three cantons and at least three", func(t *testing.T) {
+		require.Equal(t, 3, rules.Reinforcements(1))
+		require.Equal(t, 3, rules.Reinforcements(11))
+		require.Equal(t, 4, rules.Reinforcements(12))
+	})
+
+	t.Run("tells whether a roll favors the attacker", func(t *testing.T) {
+		require.True(t, rules.IsAttackSuccessful([]int{6, 5, 1}, []int{4, 4}))
+		require.False(t, rules.IsAttackSuccessful([]int{6, 3}, []int{4, 4}), "Should split the losses")
+		require.False(t, rules.IsAttackSuccessful([]int{3}, []int{3}), "Should favor the defender on ties")
+	})
+
+	t.Run("allows a single maneuver by default", func(t *testing.T) {
+		require.Equal(t, 1, rules.MaxManeuvers())
+	})
+}
@@ -0,0 +1,56 @@
+package game
+
+import "math"
+
+// MoveFeature identifies a feature describing a move, which rollout policies
+// weigh to tell sensible moves from absurd ones
+type MoveFeature int
+
+const (
+	AttackFeature   MoveFeature = iota // Log of the troop ratio of an attack
+	PassFeature                        // Passing
+	TradeFeature                       // Trading in a set of cards
+	TroopsFeature                      // Share of the available troops placed, moved or advanced
+	BorderFeature                      // Placing, moving or advancing troops onto a canton bordering an enemy
+	InteriorFeature                    // Moving troops out of a canton not bordering an enemy
+	NumMoveFeatures
+)
+
+// AttackRatio returns the ratio of the troops an attack commits to the troops
+// defending the attacked canton
+func (gs *GameState) AttackRatio(move *GameMove) float64 {
+	return float64(move.NumTroops) / float64(gs.TroopCounts[move.ToCantonID])
+}
+
+// MoveFeatures describes a legal move of the state by its features
+func (gs *GameState) MoveFeatures(move *GameMove) [NumMoveFeatures]float64 {
+	var features [NumMoveFeatures]float64
+	switch move.ActionType {
+	case AttackAction:
+		features[AttackFeature] = math.Log(gs.AttackRatio(move))
+	case PassAction:
+		features[PassFeature] = 1
+	case TradeCardsAction:
+		features[TradeFeature] = 1
+	case ReinforceAction:
+		if gs.Phase == ReinforcementPhase {
+			features[TroopsFeature] = float64(move.NumTroops) / float64(gs.TroopsToPlace)
+		}
+		features[BorderFeature] = boolFeature(gs.bordersEnemy(move.ToCantonID))
+	case ManeuverAction:
+		features[TroopsFeature] = float64(move.NumTroops) / float64(gs.TroopCounts[move.FromCantonID]-1)
+		features[BorderFeature] = boolFeature(gs.bordersEnemy(move.ToCantonID))
+		features[InteriorFeature] = boolFeature(!gs.bordersEnemy(move.FromCantonID))
+	case OccupyAction:
+		features[TroopsFeature] = float64(move.NumTroops) / float64(gs.TroopCounts[move.ToCantonID]+gs.TroopCounts[move.FromCantonID]-1)
+		features[BorderFeature] = boolFeature(gs.bordersEnemy(move.ToCantonID))
+	}
+	return features
+}
+
+func boolFeature(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
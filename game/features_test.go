@@ -0,0 +1,54 @@
+package game
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveFeatures(t *testing.T) {
+	t.Run("describes attacks by their troop ratio", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.TroopCounts[7] = 3
+		attack := &GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7, NumTroops: 999}
+
+		got := gs.MoveFeatures(attack)
+
+		require.Equal(t, 333.0, gs.AttackRatio(attack))
+		require.Equal(t, [NumMoveFeatures]float64{AttackFeature: math.Log(333)}, got)
+	})
+
+	t.Run("describes passing and trading in cards", func(t *testing.T) {
+		gs := newEliminationState()
+
+		require.Equal(t, [NumMoveFeatures]float64{PassFeature: 1}, gs.MoveFeatures(&GameMove{ActionType: PassAction}))
+		require.Equal(t, [NumMoveFeatures]float64{TradeFeature: 1}, gs.MoveFeatures(&GameMove{ActionType: TradeCardsAction}))
+	})
+
+	t.Run("describes reinforcements by the share of troops placed at the front", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.Phase = ReinforcementPhase
+		gs.TroopsToPlace = 4
+
+		got := gs.MoveFeatures(&GameMove{ActionType: ReinforceAction, ToCantonID: 22, NumTroops: 2})
+
+		require.Equal(t, [NumMoveFeatures]float64{TroopsFeature: 0.5, BorderFeature: 1}, got)
+	})
+
+	t.Run("describes maneuvers by the share of troops moved from the interior to the front", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.Phase = ManeuverPhase
+		interior := 22
+		for _, adjID := range gs.Map.Cantons[interior].AdjacentIDs {
+			gs.Ownership[adjID] = 1
+		}
+		front := 3 // Bern borders Vaud and cantons of other players
+		require.Contains(t, gs.Map.Cantons[interior].AdjacentIDs, front)
+		require.True(t, gs.bordersEnemy(front))
+
+		got := gs.MoveFeatures(&GameMove{ActionType: ManeuverAction, FromCantonID: interior, ToCantonID: front, NumTroops: 999})
+
+		require.Equal(t, [NumMoveFeatures]float64{TroopsFeature: 1, BorderFeature: 1, InteriorFeature: 1}, got)
+	})
+}
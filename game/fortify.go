@@ -0,0 +1,45 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// hasFortified tells whether troops were maneuvered out of a canton this turn
+// under the SourceFortify rule
+func (gs *GameState) hasFortified(cantonID int) bool {
+	return slices.Contains(gs.Fortified, cantonID)
+}
+
+// maneuver moves troops between two cantons of the current player as the fortify
+// rule allows, and ends the phase once the maneuvers allowed are made. Unlimited
+// maneuvers are capped at as many per turn as there are cantons on the map, in
+// total rather than per source canton, so that playouts moving troops back and
+// forth still end the turn.
+func (gs *GameState) maneuver(fromID, toID, numTroops int) error {
+	fortify := gs.Rules.FortifyRule()
+	if fortify == AdjacentFortify && !gs.AreAdjacent(fromID, toID) {
+		return fmt.Errorf("cannot maneuver: cantons %d and %d are not adjacent", fromID, toID)
+	}
+	if gs.hasFortified(fromID) {
+		return fmt.Errorf("cannot maneuver: troops already moved out of canton %d this turn", fromID)
+	}
+	if err := gs.MoveTroops(fromID, toID, numTroops); err != nil {
+		return err
+	}
+	if fortify == SourceFortify {
+		gs.addFortified(fromID)
+	}
+
+	maneuvers := gs.Maneuvers + 1
+	limit := gs.Rules.MaxManeuvers()
+	if limit < 0 {
+		limit = len(gs.TroopCounts)
+	}
+	if maneuvers >= limit {
+		gs.advancePhase()
+	} else {
+		gs.setManeuvers(maneuvers)
+	}
+	return nil
+}
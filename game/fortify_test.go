@@ -0,0 +1,169 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFortifyRules(t *testing.T) {
+	// Player 1 holds VD, a neighbor of VD and a neighbor of that neighbor not adjacent to VD
+	newFortifyState := func(fortify FortifyRule, maneuvers int) (gs *GameState, near, far int) {
+		gs = newEliminationState()
+		gs.Rules = &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Fortify: fortify, Maneuvers: maneuvers}
+		gs.Phase = ManeuverPhase
+		adjacent := gs.Map.Cantons[22].AdjacentIDs
+		for _, near = range adjacent {
+			for _, far = range gs.Map.Cantons[near].AdjacentIDs {
+				if far != 22 && !slices.Contains(adjacent, far) {
+					gs.Ownership[near], gs.Ownership[far] = 1, 1
+					gs.TroopCounts[near] = 10
+					return gs, near, far
+				}
+			}
+		}
+		panic("no canton two steps away from VD")
+	}
+	maneuver := func(from, to int) *GameMove {
+		return &GameMove{ActionType: ManeuverAction, FromCantonID: from, ToCantonID: to, NumTroops: 1}
+	}
+	offers := func(gs *GameState, from, to int) bool {
+		return slices.ContainsFunc(gs.LegalMoves(), func(move Move) bool {
+			gm := move.(*GameMove)
+			return gm.ActionType == ManeuverAction && gm.FromCantonID == from && gm.ToCantonID == to
+		})
+	}
+
+	t.Run("moves between connected cantons by default", func(t *testing.T) {
+		gs, near, far := newFortifyState(ConnectedFortify, 0)
+
+		require.True(t, offers(gs, 22, near))
+		require.True(t, offers(gs, 22, far))
+		require.NoError(t, gs.ValidateMove(maneuver(22, far)))
+	})
+
+	t.Run("moves between adjacent cantons only", func(t *testing.T) {
+		gs, near, far := newFortifyState(AdjacentFortify, 0)
+
+		require.True(t, offers(gs, 22, near))
+		require.False(t, offers(gs, 22, far), "Should not reach beyond the neighbors")
+		require.ErrorIs(t, gs.ValidateMove(maneuver(22, far)), ErrNotAdjacent)
+		require.Panics(t, func() { gs.Play(maneuver(22, far)) })
+	})
+
+	t.Run("moves out of each canton once", func(t *testing.T) {
+		gs, near, far := newFortifyState(SourceFortify, UnlimitedManeuvers)
+
+		got := gs.Play(maneuver(22, near)).(*GameState)
+
+		require.Equal(t, ManeuverPhase, got.Phase, "Should keep maneuvering")
+		require.Equal(t, []int{22}, got.Fortified)
+		require.False(t, offers(got, 22, far), "Should not move out of a canton twice")
+		require.ErrorIs(t, got.ValidateMove(maneuver(22, far)), ErrFortified)
+		require.True(t, offers(got, near, far), "Should move out of the other cantons")
+
+		got = got.Play(maneuver(near, far)).Play(&GameMove{ActionType: PassAction}).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase)
+		require.Empty(t, got.Fortified, "Should clear the cantons moved out of for the next turn")
+		require.Equal(t, got.computeHash(), got.Hash(), "Should keep the hash up to date")
+	})
+
+	t.Run("plays random games in place", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		for _, rules := range []*StandardRules{
+			{MaxAttackDice: 3, MaxDefendDice: 2, Fortify: AdjacentFortify},
+			{MaxAttackDice: 3, MaxDefendDice: 2, Fortify: AdjacentFortify, Maneuvers: UnlimitedManeuvers},
+			{MaxAttackDice: 3, MaxDefendDice: 2, Fortify: SourceFortify, Maneuvers: UnlimitedManeuvers},
+		} {
+			rng := rand.New(rand.NewSource(1))
+			var state State = NewGameState(CreateMap(), rules, WithPlayers(3), WithSeed(1))
+			r := NewGameState(CreateMap(), rules, WithPlayers(3), WithSeed(1)).Rollout()
+			start := r.State().Hash()
+			played := 0
+			for ; played < 2000 && state.Winner() == ""; played++ {
+				moves := state.LegalMoves()
+				for _, move := range moves {
+					require.NoError(t, state.(*GameState).ValidateMove(move))
+				}
+				index := rng.Intn(len(moves))
+				state = state.Play(moves[index])
+				r.Apply(r.LegalMoves()[index])
+				require.Equal(t, state.Hash(), r.State().Hash(), "Should fortify the same way in place")
+			}
+
+			for i := 0; i < played; i++ {
+				r.Undo()
+			}
+			require.Equal(t, start, r.State().Hash(), "Should undo the maneuvers")
+		}
+	})
+
+	t.Run("moves out of a canton again till the maneuvers of the turn run out", func(t *testing.T) {
+		for _, fortify := range []FortifyRule{ConnectedFortify, AdjacentFortify} {
+			gs, near, _ := newFortifyState(fortify, UnlimitedManeuvers)
+			numCantons := len(gs.TroopCounts)
+
+			var state State = gs
+			for i := 0; i < numCantons-1; i++ {
+				from, to := 22, near
+				if i%2 == 1 {
+					from, to = near, 22
+				}
+				require.NoError(t, state.(*GameState).ValidateMove(maneuver(from, to)), "Should reuse canton %d", from)
+				state = state.Play(maneuver(from, to))
+				require.Equal(t, ManeuverPhase, state.(*GameState).Phase)
+			}
+			require.Empty(t, state.(*GameState).Fortified)
+
+			state = state.Play(maneuver(22, near))
+
+			require.Equal(t, ReinforcementPhase, state.(*GameState).Phase, "Should end the turn after a maneuver per canton")
+		}
+	})
+
+	t.Run("ends playouts that keep maneuvering", func(t *testing.T) {
+		for _, fortify := range []FortifyRule{ConnectedFortify, AdjacentFortify} {
+			rules := &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Fortify: fortify, Maneuvers: UnlimitedManeuvers}
+			rng := rand.New(rand.NewSource(1))
+			var state State = NewGameState(CreateMap(), rules, WithPlayers(3), WithSeed(1))
+			numCantons := len(state.(*GameState).TroopCounts)
+			played, maneuvers := 0, 0
+			for ; played < 100000 && state.Winner() == ""; played++ {
+				moves := state.LegalMoves()
+				move := moves[rng.Intn(len(moves))]
+				if state.(*GameState).Phase == ManeuverPhase && len(moves) > 1 { // Never pass while troops can move
+					move = moves[rng.Intn(len(moves)-1)]
+					maneuvers++
+					require.LessOrEqual(t, maneuvers, numCantons, "Should cap the maneuvers of a turn at one per canton on the map")
+				} else {
+					maneuvers = 0
+				}
+				state = state.Play(move)
+			}
+			require.NotEmpty(t, state.Winner(), "Should finish the game after %d moves", played)
+		}
+	})
+
+	t.Run("encodes the cantons moved out of", func(t *testing.T) {
+		gs, near, _ := newFortifyState(SourceFortify, UnlimitedManeuvers)
+		gs = gs.Play(maneuver(22, near)).(*GameState)
+		gs.rng = nil
+
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(data, &got))
+		got.owned, got.copied = gs.owned, gs.copied // Whether memory is shared with other states is not encoded
+
+		require.Equal(t, gs.Fortified, got.Fortified)
+		require.Equal(t, gs.Rules, got.Rules)
+		require.Equal(t, gs.Hash(), got.Hash())
+		require.Equal(t, gs.LegalMoves(), got.LegalMoves())
+	})
+}
@@ -0,0 +1,226 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// BonusPolicy decides the bonus troops awarded for holding a whole region
+type BonusPolicy func(m *Map, region *Region) int
+
+// ExposureBonus scales the bonus with the size of the region and the number of
+// its cantons bordering other regions, which approximates the classic map bonuses
+func ExposureBonus(m *Map, region *Region) int {
+	exposed := 0
+	for _, cantonID := range region.CantonIDs {
+		for _, adjID := range m.Cantons[cantonID].AdjacentIDs {
+			if m.Cantons[adjID].RegionID != region.ID {
+				exposed++
+				break
+			}
+		}
+	}
+	return max(1, int(math.Round(float64(len(region.CantonIDs)+exposed)/3)))
+}
+
+// FlatBonus awards the same bonus for every region regardless of its shape
+func FlatBonus(bonus int) BonusPolicy {
+	return func(m *Map, region *Region) int {
+		return bonus
+	}
+}
+
+// GeneratorConfig describes a family of procedurally generated maps
+type GeneratorConfig struct {
+	Territories   int
+	Regions       int
+	AverageDegree float64     // Number of neighbors per canton, capped by what a planar graph allows
+	Bonus         BonusPolicy // ExposureBonus if nil
+	Seed          int64
+}
+
+type point struct {
+	x, y float64
+}
+
+type edge struct {
+	from, to int
+	length   float64
+}
+
+// GenerateMap builds a random planar-ish map. Cantons are scattered as points in
+// the unit square and connected by a minimum spanning tree, then by the shortest
+// edges that do not cross an existing border until the average degree is reached.
+// Regions are grown from random cantons so each one is a connected subgraph. The
+// same config always generates the same map.
+func GenerateMap(config GeneratorConfig) (*Map, error) {
+	if config.Regions < 1 || config.Territories < max(2, config.Regions) {
+		return nil, fmt.Errorf("need at least 2 territories and 1 region with no more regions than territories, got %d territories and %d regions", config.Territories, config.Regions)
+	}
+	if config.AverageDegree < 0 {
+		return nil, fmt.Errorf("average degree must not be negative, got %v", config.AverageDegree)
+	}
+	bonus := config.Bonus
+	if bonus == nil {
+		bonus = ExposureBonus
+	}
+	rng := rand.New(rand.NewSource(config.Seed))
+
+	m := NewMap()
+	m.Name = fmt.Sprintf("generated-%d-%d-%d", config.Territories, config.Regions, config.Seed)
+
+	points := make([]point, config.Territories)
+	for i := range points {
+		points[i] = point{x: rng.Float64(), y: rng.Float64()}
+		m.AddCanton(&Canton{ID: i, Name: fmt.Sprintf("Territory %d", i+1), Abbreviation: fmt.Sprintf("T%d", i+1)})
+	}
+
+	for _, e := range planarEdges(points, config.AverageDegree) {
+		m.AddBorder(e.from, e.to)
+	}
+
+	for i, region := range growRegions(m, config.Regions, rng) {
+		regionID := i + 1
+		m.Regions[regionID] = &Region{ID: regionID, Name: fmt.Sprintf("Region %d", regionID), CantonIDs: region}
+		for _, cantonID := range region {
+			m.Cantons[cantonID].RegionID = regionID
+		}
+	}
+	for _, regionID := range sortedKeys(m.Regions) {
+		m.Regions[regionID].Bonus = bonus(m, m.Regions[regionID])
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("generated an invalid map: %w", err)
+	}
+	return m, nil
+}
+
+// planarEdges connects the points with a minimum spanning tree and adds the
+// shortest non-crossing edges until the average degree is reached
+func planarEdges(points []point, averageDegree float64) []edge {
+	n := len(points)
+	var candidates []edge
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			candidates = append(candidates, edge{from: i, to: j, length: math.Hypot(points[i].x-points[j].x, points[i].y-points[j].y)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].length < candidates[j].length })
+
+	// Kruskal's algorithm, the Euclidean minimum spanning tree never crosses itself
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	var edges []edge
+	used := make([]bool, len(candidates))
+	for i, e := range candidates {
+		if rootFrom, rootTo := find(e.from), find(e.to); rootFrom != rootTo {
+			parent[rootFrom] = rootTo
+			edges = append(edges, e)
+			used[i] = true
+		}
+	}
+
+	target := int(math.Round(float64(n) * averageDegree / 2))
+	for i, e := range candidates {
+		if len(edges) >= target {
+			break
+		}
+		if used[i] || crossesAny(points, e, edges) {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+func crossesAny(points []point, e edge, edges []edge) bool {
+	for _, other := range edges {
+		if e.from == other.from || e.from == other.to || e.to == other.from || e.to == other.to {
+			continue // Edges sharing a canton only touch at that canton
+		}
+		if crosses(points[e.from], points[e.to], points[other.from], points[other.to]) {
+			return true
+		}
+	}
+	return false
+}
+
+// crosses checks if segments ab and cd properly intersect
+func crosses(a, b, c, d point) bool {
+	orientation := func(p, q, r point) float64 {
+		return (q.x-p.x)*(r.y-p.y) - (q.y-p.y)*(r.x-p.x)
+	}
+	return orientation(a, b, c)*orientation(a, b, d) < 0 && orientation(c, d, a)*orientation(c, d, b) < 0
+}
+
+// growRegions partitions the cantons into connected regions grown from random
+// seed cantons, always growing the smallest region that can still expand
+func growRegions(m *Map, numRegions int, rng *rand.Rand) [][]int {
+	assigned := make(map[int]bool)
+	regions := make([][]int, numRegions)
+	for i, cantonID := range rng.Perm(len(m.Cantons))[:numRegions] {
+		regions[i] = []int{cantonID}
+		assigned[cantonID] = true
+	}
+
+	for len(assigned) < len(m.Cantons) {
+		grown := false
+		for _, i := range regionsBySize(regions) {
+			frontier := regionFrontier(m, regions[i], assigned)
+			if len(frontier) == 0 {
+				continue
+			}
+			cantonID := frontier[rng.Intn(len(frontier))]
+			regions[i] = append(regions[i], cantonID)
+			assigned[cantonID] = true
+			grown = true
+			break
+		}
+		if !grown {
+			panic("failed to grow regions over a disconnected map")
+		}
+	}
+
+	for _, region := range regions {
+		sort.Ints(region)
+	}
+	return regions
+}
+
+// regionsBySize orders region indices from the smallest to the largest region
+func regionsBySize(regions [][]int) []int {
+	order := make([]int, len(regions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(regions[order[i]]) < len(regions[order[j]]) })
+	return order
+}
+
+// regionFrontier lists the unassigned cantons bordering a region in ascending order
+func regionFrontier(m *Map, region []int, assigned map[int]bool) []int {
+	seen := make(map[int]bool)
+	var frontier []int
+	for _, cantonID := range region {
+		for _, adjID := range m.Cantons[cantonID].AdjacentIDs {
+			if !assigned[adjID] && !seen[adjID] {
+				seen[adjID] = true
+				frontier = append(frontier, adjID)
+			}
+		}
+	}
+	sort.Ints(frontier)
+	return frontier
+}
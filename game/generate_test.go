@@ -0,0 +1,96 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMap(t *testing.T) {
+	config := GeneratorConfig{Territories: 42, Regions: 6, AverageDegree: 4, Seed: 7}
+
+	t.Run("generates a valid map", func(t *testing.T) {
+		for seed := int64(0); seed < 20; seed++ {
+			config := config
+			config.Seed = seed
+			m, err := GenerateMap(config)
+
+			require.NoError(t, err)
+			require.NoError(t, m.Validate())
+			require.Len(t, m.Cantons, 42)
+			require.Len(t, m.Regions, 6)
+		}
+	})
+
+	t.Run("is reproducible by seed", func(t *testing.T) {
+		a, err := GenerateMap(config)
+		require.NoError(t, err)
+		b, err := GenerateMap(config)
+		require.NoError(t, err)
+		require.Equal(t, a, b, "Same seed should generate the same map")
+
+		config.Seed++
+		c, err := GenerateMap(config)
+		require.NoError(t, err)
+		require.NotEmpty(t, DiffMaps(a, c), "Different seeds should generate different maps")
+	})
+
+	t.Run("approaches the average degree", func(t *testing.T) {
+		m, err := GenerateMap(config)
+		require.NoError(t, err)
+
+		require.Len(t, borders(m), 84, "Should add borders up to the average degree")
+	})
+
+	t.Run("keeps regions connected", func(t *testing.T) {
+		m, err := GenerateMap(config)
+		require.NoError(t, err)
+
+		for _, region := range m.Regions {
+			reached := map[int]bool{region.CantonIDs[0]: true}
+			queue := []int{region.CantonIDs[0]}
+			for len(queue) > 0 {
+				current := queue[0]
+				queue = queue[1:]
+				for _, adjID := range m.Cantons[current].AdjacentIDs {
+					if m.Cantons[adjID].RegionID == region.ID && !reached[adjID] {
+						reached[adjID] = true
+						queue = append(queue, adjID)
+					}
+				}
+			}
+			require.Len(t, reached, len(region.CantonIDs), "Region %d should be connected", region.ID)
+		}
+	})
+
+	t.Run("applies the bonus policy", func(t *testing.T) {
+		config := config
+		config.Bonus = FlatBonus(4)
+		m, err := GenerateMap(config)
+		require.NoError(t, err)
+
+		for _, region := range m.Regions {
+			require.Equal(t, 4, region.Bonus)
+		}
+	})
+
+	t.Run("rejects invalid configs", func(t *testing.T) {
+		for _, config := range []GeneratorConfig{
+			{Territories: 1, Regions: 1},
+			{Territories: 4, Regions: 0},
+			{Territories: 4, Regions: 5},
+			{Territories: 4, Regions: 2, AverageDegree: -1},
+		} {
+			_, err := GenerateMap(config)
+			require.Error(t, err, "%+v", config)
+		}
+	})
+}
+
+func TestExposureBonus(t *testing.T) {
+	m := CreateClassicMap()
+
+	for _, region := range m.Regions {
+		require.InDelta(t, region.Bonus, ExposureBonus(m, region), 2, "%s bonus should be close to the classic bonus", region.Name)
+	}
+}
@@ -0,0 +1,362 @@
+package game
+
+import "fmt"
+
+// DebugHash cross-checks the incremental hash against a full recompute after
+// every move and on every call to Hash, panicking on a mismatch. It is slow and
+// meant for tests and debugging only.
+var DebugHash = false
+
+// hashFeature identifies a component of the state in its Zobrist key
+type hashFeature uint64
+
+const (
+	currentPlayerFeature hashFeature = iota + 1
+	phaseFeature
+	numPlayersFeature
+	troopsFeature // Troops per canton
+	ownerFeature  // Owner per canton
+	troopsToPlaceFeature
+	playerTroopsFeature // Initial placement troops per player
+	handFeature         // Card per player and hand position
+	deckFeature         // Card per position from the bottom of the deck
+	discardFeature      // Card per number of copies already discarded
+	exchangesFeature
+	conqueredFeature
+	eliminatedFeature // Player per order of elimination
+	wonFeature
+	occupationFeature
+	battleFeature
+	maneuversFeature
+	fortifiedFeature // Canton troops were maneuvered out of
+)
+
+// zobristKey derives the random key of a state feature with the given values.
+// Keys are computed on the fly with the SplitMix64 finalizer rather than drawn
+// from a table, so features with unbounded values such as troop counts need no
+// table size.
+func zobristKey(feature hashFeature, a, b, c int) StateHash {
+	x := uint64(feature)
+	for _, v := range [...]int{a, b, c} {
+		x = mix64(x ^ uint64(v))
+	}
+	return StateHash(x)
+}
+
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func cardKey(feature hashFeature, card RiskCard, a int) StateHash {
+	return zobristKey(feature, a, int(card.Type), card.TerritoryID)
+}
+
+func handKey(playerID, index int, card RiskCard) StateHash {
+	return cardKey(handFeature, card, playerID<<16|index)
+}
+
+func handHash(playerID int, hand []RiskCard) StateHash {
+	var h StateHash
+	for i, card := range hand {
+		h ^= handKey(playerID, i, card)
+	}
+	return h
+}
+
+// deckHash keys cards by their position from the bottom of the deck, which
+// does not change as cards are drawn from the top
+func deckHash(deck []RiskCard) StateHash {
+	var h StateHash
+	for i, card := range deck {
+		h ^= cardKey(deckFeature, card, len(deck)-1-i)
+	}
+	return h
+}
+
+// discardHash keys cards by how many copies of the card were discarded before
+// them, so the order in which cards are discarded does not matter
+func discardHash(discarded []RiskCard) StateHash {
+	var h StateHash
+	copies := make(map[RiskCard]int)
+	for _, card := range discarded {
+		h ^= cardKey(discardFeature, card, copies[card])
+		copies[card]++
+	}
+	return h
+}
+
+func conqueredKey(conquered bool) StateHash {
+	if !conquered {
+		return 0
+	}
+	return zobristKey(conqueredFeature, 0, 0, 0)
+}
+
+// occupationKey keys a pending occupation, leaving the hash of states with
+// none untouched
+func occupationKey(occupation Occupation) StateHash {
+	if occupation == (Occupation{}) {
+		return 0
+	}
+	return zobristKey(occupationFeature, occupation.From, occupation.To, 0)
+}
+
+// battleKey keys a pending battle, leaving the hash of states with none untouched
+func battleKey(battle Battle) StateHash {
+	if battle == (Battle{}) {
+		return 0
+	}
+	return zobristKey(battleFeature, battle.From, battle.To, battle.Troops)
+}
+
+// maneuversKey keys the maneuvers made this turn, leaving the hash of states
+// with none untouched
+func maneuversKey(maneuvers int) StateHash {
+	if maneuvers == 0 {
+		return 0
+	}
+	return zobristKey(maneuversFeature, maneuvers, 0, 0)
+}
+
+func wonKey(won string) StateHash {
+	if won == "" {
+		return 0
+	}
+	var playerID int
+	fmt.Sscanf(won, "Player%d", &playerID)
+	return zobristKey(wonFeature, playerID, 0, 0)
+}
+
+// computeHash hashes the full state from scratch
+func (gs *GameState) computeHash() StateHash {
+	h := zobristKey(currentPlayerFeature, gs.CurrentPlayer, 0, 0) ^
+		zobristKey(phaseFeature, int(gs.Phase), 0, 0) ^
+		zobristKey(numPlayersFeature, gs.NumPlayers, 0, 0) ^
+		zobristKey(troopsToPlaceFeature, gs.TroopsToPlace, 0, 0) ^
+		zobristKey(exchangesFeature, gs.Exchanges, 0, 0) ^
+		conqueredKey(gs.ConqueredThisTurn) ^
+		occupationKey(gs.Occupation) ^
+		battleKey(gs.Battle) ^
+		maneuversKey(gs.Maneuvers) ^
+		wonKey(gs.Won)
+	for cantonID := range gs.TroopCounts {
+		h ^= zobristKey(troopsFeature, cantonID, gs.TroopCounts[cantonID], 0)
+		h ^= zobristKey(ownerFeature, cantonID, gs.Ownership[cantonID], 0)
+	}
+	for playerID, troops := range gs.PlayerTroops {
+		h ^= zobristKey(playerTroopsFeature, playerID, troops, 0)
+	}
+	for playerID, hand := range gs.PlayerHands {
+		h ^= handHash(playerID, hand)
+	}
+	h ^= deckHash(gs.Cards) ^ discardHash(gs.DiscardedCards)
+	for _, cantonID := range gs.Fortified {
+		h ^= zobristKey(fortifiedFeature, cantonID, 0, 0)
+	}
+	for i, playerID := range gs.Eliminated {
+		h ^= zobristKey(eliminatedFeature, i, playerID, 0)
+	}
+	return h
+}
+
+// Hash returns the Zobrist hash of the state, covering every field but the
+// map, rules and last move. It is kept up to date incrementally by moves.
+func (gs GameState) Hash() StateHash {
+	if !gs.hashed {
+		return gs.computeHash()
+	}
+	if DebugHash {
+		gs.checkHash()
+	}
+	return gs.hash
+}
+
+// Rehash recomputes the hash from scratch. States are hashed on their first
+// move, so it only needs to be called after editing the fields of a state
+// that has already been played.
+func (gs *GameState) Rehash() {
+	gs.hash = gs.computeHash()
+	gs.hashed = true
+}
+
+func (gs *GameState) checkHash() {
+	if full := gs.computeHash(); gs.hashed && gs.hash != full {
+		panic(fmt.Sprintf("incremental hash %d does not match full hash %d after move %+v", gs.hash, full, gs.LastMove))
+	}
+}
+
+// toggle adds or removes a key from the hash of a hashed state
+func (gs *GameState) toggle(key StateHash) {
+	if gs.hashed {
+		gs.hash ^= key
+	}
+}
+
+// The setters below change a field, update the hash accordingly and journal
+// the change when played in place by a rollout. They copy the components of
+// the state shared with other states before changing them in place. Moves must
+// only change fields through them.
+
+func (gs *GameState) setCurrentPlayer(playerID int) {
+	gs.record(change{kind: currentPlayerChange, value: gs.CurrentPlayer})
+	gs.toggle(zobristKey(currentPlayerFeature, gs.CurrentPlayer, 0, 0) ^ zobristKey(currentPlayerFeature, playerID, 0, 0))
+	gs.CurrentPlayer = playerID
+}
+
+func (gs *GameState) setPhase(phase Phase) {
+	gs.record(change{kind: phaseChange, value: int(gs.Phase)})
+	gs.toggle(zobristKey(phaseFeature, int(gs.Phase), 0, 0) ^ zobristKey(phaseFeature, int(phase), 0, 0))
+	gs.Phase = phase
+}
+
+func (gs *GameState) setTroops(cantonID, troops int) {
+	gs.record(change{kind: troopsChange, index: cantonID, value: gs.TroopCounts[cantonID]})
+	gs.unshare(troopsComponent)
+	gs.toggle(zobristKey(troopsFeature, cantonID, gs.TroopCounts[cantonID], 0) ^ zobristKey(troopsFeature, cantonID, troops, 0))
+	gs.TroopCounts[cantonID] = troops
+}
+
+func (gs *GameState) setOwner(cantonID, playerID int) {
+	gs.record(change{kind: ownerChange, index: cantonID, value: gs.Ownership[cantonID]})
+	gs.unshare(ownersComponent)
+	gs.toggle(zobristKey(ownerFeature, cantonID, gs.Ownership[cantonID], 0) ^ zobristKey(ownerFeature, cantonID, playerID, 0))
+	gs.Ownership[cantonID] = playerID
+}
+
+// setPlayerTroops sets the armies a player has left to place during the setup
+// phase, who must have been dealt armies when the game was set up
+func (gs *GameState) setPlayerTroops(playerID, troops int) {
+	gs.record(change{kind: playerTroopsChange, index: playerID, value: gs.PlayerTroops[playerID]})
+	gs.unshare(playerTroopsComponent)
+	gs.toggle(zobristKey(playerTroopsFeature, playerID, gs.PlayerTroops[playerID], 0) ^ zobristKey(playerTroopsFeature, playerID, troops, 0))
+	gs.PlayerTroops[playerID] = troops
+}
+
+func (gs *GameState) setTroopsToPlace(troops int) {
+	gs.record(change{kind: troopsToPlaceChange, value: gs.TroopsToPlace})
+	gs.toggle(zobristKey(troopsToPlaceFeature, gs.TroopsToPlace, 0, 0) ^ zobristKey(troopsToPlaceFeature, troops, 0, 0))
+	gs.TroopsToPlace = troops
+}
+
+func (gs *GameState) setExchanges(exchanges int) {
+	gs.record(change{kind: exchangesChange, value: gs.Exchanges})
+	gs.toggle(zobristKey(exchangesFeature, gs.Exchanges, 0, 0) ^ zobristKey(exchangesFeature, exchanges, 0, 0))
+	gs.Exchanges = exchanges
+}
+
+func (gs *GameState) setConquered(conquered bool) {
+	if gs.ConqueredThisTurn {
+		gs.record(change{kind: conqueredChange, value: 1})
+	} else {
+		gs.record(change{kind: conqueredChange})
+	}
+	gs.toggle(conqueredKey(gs.ConqueredThisTurn) ^ conqueredKey(conquered))
+	gs.ConqueredThisTurn = conquered
+}
+
+func (gs *GameState) setOccupation(occupation Occupation) {
+	gs.record(change{kind: occupationChange, index: gs.Occupation.From, value: gs.Occupation.To})
+	gs.toggle(occupationKey(gs.Occupation) ^ occupationKey(occupation))
+	gs.Occupation = occupation
+}
+
+func (gs *GameState) setBattle(battle Battle) {
+	gs.record(change{kind: battleChange, battle: gs.Battle})
+	gs.toggle(battleKey(gs.Battle) ^ battleKey(battle))
+	gs.Battle = battle
+}
+
+func (gs *GameState) setManeuvers(maneuvers int) {
+	gs.record(change{kind: maneuversChange, value: gs.Maneuvers})
+	gs.toggle(maneuversKey(gs.Maneuvers) ^ maneuversKey(maneuvers))
+	gs.Maneuvers = maneuvers
+}
+
+func (gs *GameState) addFortified(cantonID int) {
+	gs.record(change{kind: fortifiedChange, ints: gs.Fortified})
+	gs.unshare(fortifiedComponent)
+	gs.toggle(zobristKey(fortifiedFeature, cantonID, 0, 0))
+	gs.Fortified = append(gs.Fortified, cantonID)
+}
+
+func (gs *GameState) clearFortified() {
+	gs.record(change{kind: fortifiedChange, ints: gs.Fortified})
+	for _, cantonID := range gs.Fortified {
+		gs.toggle(zobristKey(fortifiedFeature, cantonID, 0, 0))
+	}
+	// Keep appending after the cantons cleared, which undoing may restore,
+	// rather than over them
+	gs.Fortified = gs.Fortified[len(gs.Fortified):]
+}
+
+func (gs *GameState) setWon(won string) {
+	gs.record(change{kind: wonChange, won: gs.Won})
+	gs.toggle(wonKey(gs.Won) ^ wonKey(won))
+	gs.Won = won
+}
+
+// setHand replaces a player's hand. The new hand must not overwrite the cards
+// of the current hand in place, nor be shared with other states.
+func (gs *GameState) setHand(playerID int, hand []RiskCard) {
+	gs.record(change{kind: handChange, index: playerID, cards: gs.PlayerHands[playerID]})
+	gs.unshare(handsComponent)
+	gs.toggle(handHash(playerID, gs.PlayerHands[playerID]) ^ handHash(playerID, hand))
+	gs.PlayerHands[playerID] = hand
+}
+
+func (gs *GameState) addToHand(playerID int, card RiskCard) {
+	gs.record(change{kind: handChange, index: playerID, cards: gs.PlayerHands[playerID]})
+	gs.unshare(handComponent(playerID))
+	gs.toggle(handKey(playerID, len(gs.PlayerHands[playerID]), card))
+	gs.PlayerHands[playerID] = append(gs.PlayerHands[playerID], card)
+}
+
+func (gs *GameState) setDeck(deck []RiskCard) {
+	gs.record(change{kind: deckChange, cards: gs.Cards})
+	gs.toggle(deckHash(gs.Cards) ^ deckHash(deck))
+	gs.Cards = deck
+}
+
+// drawTop removes the top card of a non-empty deck
+func (gs *GameState) drawTop() RiskCard {
+	card := gs.Cards[0]
+	gs.record(change{kind: deckChange, cards: gs.Cards})
+	gs.toggle(cardKey(deckFeature, card, len(gs.Cards)-1))
+	gs.Cards = gs.Cards[1:]
+	return card
+}
+
+func (gs *GameState) setDiscarded(discarded []RiskCard) {
+	gs.record(change{kind: discardedChange, cards: gs.DiscardedCards})
+	gs.toggle(discardHash(gs.DiscardedCards) ^ discardHash(discarded))
+	gs.DiscardedCards = discarded
+}
+
+func (gs *GameState) discard(card RiskCard) {
+	copies := 0
+	for _, discarded := range gs.DiscardedCards {
+		if discarded == card {
+			copies++
+		}
+	}
+	gs.toggle(cardKey(discardFeature, card, copies))
+	gs.record(change{kind: discardedChange, cards: gs.DiscardedCards})
+	gs.unshare(discardedComponent)
+	gs.DiscardedCards = append(gs.DiscardedCards, card)
+}
+
+func (gs *GameState) addEliminated(playerID int) {
+	gs.record(change{kind: eliminatedChange, ints: gs.Eliminated})
+	gs.unshare(eliminatedComponent)
+	gs.toggle(zobristKey(eliminatedFeature, len(gs.Eliminated), playerID, 0))
+	gs.Eliminated = append(gs.Eliminated, playerID)
+}
+
+// setLastMove is not hashed, but journaled like the other fields
+func (gs *GameState) setLastMove(move Move) {
+	gs.record(change{kind: lastMoveChange, move: gs.LastMove})
+	gs.LastMove = move
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	t.Run("matches a full recompute throughout random games", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rng := rand.New(rand.NewSource(1))
+		for numPlayers := MinPlayers; numPlayers <= MaxPlayers; numPlayers++ {
+			var state State = NewGameState(CreateMap(), NewStandardRules(), WithPlayers(numPlayers), WithRand(rng))
+			for i := 0; i < 5000 && state.Winner() == ""; i++ {
+				moves := state.LegalMoves()
+				require.NotPanics(t, func() { state = state.Play(moves[rng.Intn(len(moves))]) })
+			}
+		}
+	})
+
+	t.Run("covers cards, exchanges and conquests", func(t *testing.T) {
+		base := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithSeed(1))
+		base.Rehash()
+		edits := map[string]func(gs *GameState){
+			"hand":       func(gs *GameState) { gs.PlayerHands[1] = []RiskCard{gs.Cards[0]} },
+			"deck order": func(gs *GameState) { gs.Cards[0], gs.Cards[1] = gs.Cards[1], gs.Cards[0] },
+			"discarded":  func(gs *GameState) { gs.DiscardedCards = []RiskCard{{Type: Wild, TerritoryID: -1}} },
+			"exchanges":  func(gs *GameState) { gs.Exchanges = 1 },
+			"conquered":  func(gs *GameState) { gs.ConqueredThisTurn = true },
+			"eliminated": func(gs *GameState) { gs.Eliminated = []int{2} },
+		}
+		seen := map[StateHash]string{base.Hash(): "base"}
+		for name, edit := range edits {
+			gs := base.Copy()
+			gs.PlayerHands = [][]RiskCard{{}, {}, {}, {}}
+			gs.Cards = append([]RiskCard(nil), base.Cards...)
+			edit(&gs)
+			gs.Rehash()
+
+			other, collides := seen[gs.Hash()]
+			require.False(t, collides, "Editing the %s should change the hash, collides with %s", name, other)
+			seen[gs.Hash()] = name
+		}
+	})
+
+	t.Run("ignores the order of discarded cards", func(t *testing.T) {
+		a := NewGameState(CreateMap(), NewStandardRules())
+		b := a.Copy()
+		a.DiscardedCards = []RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Wild, TerritoryID: -1}, {Type: Wild, TerritoryID: -1}}
+		b.DiscardedCards = []RiskCard{{Type: Wild, TerritoryID: -1}, {Type: Infantry, TerritoryID: 0}, {Type: Wild, TerritoryID: -1}}
+
+		require.Equal(t, a.Hash(), b.Hash())
+	})
+
+	t.Run("updates incrementally on play", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewStandardRules(), WithSeed(2))
+		next := gs.Play(gs.LegalMoves()[0]).(*GameState)
+
+		require.True(t, next.hashed, "Played states should be hashed")
+		require.Equal(t, next.computeHash(), next.Hash())
+		require.NotEqual(t, gs.Hash(), next.Hash())
+	})
+}
+
+func BenchmarkHash(b *testing.B) {
+	gs := NewGameState(CreateClassicMap(), NewStandardRules(), WithSeed(1))
+	next := gs.Play(gs.LegalMoves()[0])
+
+	b.Run("incremental", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			next.Hash()
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			next.(*GameState).computeHash()
+		}
+	})
+}
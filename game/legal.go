@@ -0,0 +1,182 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reasons a move is rejected, wrapped by MoveError so callers can tell them
+// apart with errors.Is
+var (
+	ErrUnknownMove        = errors.New("unknown move type")
+	ErrGameOver           = errors.New("game is over")
+	ErrWrongPhase         = errors.New("action not allowed in this phase")
+	ErrUnknownCanton      = errors.New("unknown canton")
+	ErrNotOwned           = errors.New("canton not owned by the player")
+	ErrOwnCanton          = errors.New("canton owned by the player")
+	ErrNotAdjacent        = errors.New("cantons are not adjacent")
+	ErrNotConnected       = errors.New("cantons are not connected")
+	ErrInsufficientTroops = errors.New("not enough troops")
+	ErrInvalidSet         = errors.New("cards do not form a set")
+	ErrMustTrade          = errors.New("player must trade in cards first")
+	ErrClaimed            = errors.New("canton already claimed")
+	ErrNotConquered       = errors.New("canton not just conquered from the given canton")
+	ErrNotAttacked        = errors.New("canton not attacked from the given canton")
+	ErrInvalidDice        = errors.New("invalid number of dice")
+	ErrFortified          = errors.New("troops already maneuvered out of the canton this turn")
+)
+
+// MoveError explains why a move cannot be played in a state
+type MoveError struct {
+	Move   Move
+	Err    error  // One of the Err* reasons above
+	Detail string // Specifics of the move at fault
+}
+
+func (e *MoveError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("invalid move %+v: %v", e.Move, e.Err)
+	}
+	return fmt.Sprintf("invalid move %+v: %v: %s", e.Move, e.Err, e.Detail)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateMove checks whether the player to move may play a move under the
+// rules of the game, returning a *MoveError explaining why not otherwise.
+// Unlike LegalMoves, it accepts any number of troops allowed by the rules
+// rather than only the amounts offered to agents.
+func (gs GameState) ValidateMove(move Move) error {
+	gm, ok := move.(*GameMove)
+	if !ok {
+		return &MoveError{Move: move, Err: ErrUnknownMove, Detail: fmt.Sprintf("%T", move)}
+	}
+	reject := func(err error, format string, args ...any) error {
+		return &MoveError{Move: move, Err: err, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if gs.Won != "" {
+		return reject(ErrGameOver, "won by %s", gs.Won)
+	}
+	if !IsMoveValidForPhase(gs.Phase, gm) || (gs.Phase == ReinforcementPhase && gm.ActionType == PassAction) {
+		return reject(ErrWrongPhase, "action %d in phase %d", gm.ActionType, gs.Phase)
+	}
+
+	owned := func(cantonID int) error {
+		if cantonID < 0 || cantonID >= len(gs.Ownership) {
+			return reject(ErrUnknownCanton, "canton %d", cantonID)
+		}
+		if gs.Ownership[cantonID] != gs.CurrentPlayer {
+			return reject(ErrNotOwned, "canton %d owned by player %d", cantonID, gs.Ownership[cantonID])
+		}
+		return nil
+	}
+
+	switch gm.ActionType {
+	case ClaimAction:
+		if gm.ToCantonID < 0 || gm.ToCantonID >= len(gs.Ownership) {
+			return reject(ErrUnknownCanton, "canton %d", gm.ToCantonID)
+		}
+		if gs.Ownership[gm.ToCantonID] >= 0 {
+			return reject(ErrClaimed, "canton %d owned by player %d", gm.ToCantonID, gs.Ownership[gm.ToCantonID])
+		}
+
+	case TradeCardsAction:
+		hand := gs.PlayerHands[gs.CurrentPlayer]
+		if !gs.canTrade(hand, gm.CardIndices) {
+			return reject(ErrInvalidSet, "cards %v of a hand of %d", gm.CardIndices, len(hand))
+		}
+
+	case ReinforceAction:
+		if gs.Phase == PlacementPhase {
+			if err := owned(gm.ToCantonID); err != nil {
+				return err
+			}
+			if gm.NumTroops <= 0 || gm.NumTroops > gs.PlayerTroops[gs.CurrentPlayer] {
+				return reject(ErrInsufficientTroops, "placing %d of %d armies", gm.NumTroops, gs.PlayerTroops[gs.CurrentPlayer])
+			}
+			break
+		}
+		trades := &buffers{}
+		gs.tradeMoves(trades)
+		if len(gs.PlayerHands[gs.CurrentPlayer]) >= MandatoryTradeHandSize && len(trades.moves) > 0 {
+			return reject(ErrMustTrade, "holding %d cards", len(gs.PlayerHands[gs.CurrentPlayer]))
+		}
+		if err := owned(gm.ToCantonID); err != nil {
+			return err
+		}
+		if gm.NumTroops <= 0 || gm.NumTroops > gs.TroopsToPlace {
+			return reject(ErrInsufficientTroops, "placing %d of %d troops", gm.NumTroops, gs.TroopsToPlace)
+		}
+
+	case AttackAction:
+		if err := owned(gm.FromCantonID); err != nil {
+			return err
+		}
+		if gm.ToCantonID < 0 || gm.ToCantonID >= len(gs.Ownership) {
+			return reject(ErrUnknownCanton, "canton %d", gm.ToCantonID)
+		}
+		if gs.Ownership[gm.ToCantonID] == gs.CurrentPlayer {
+			return reject(ErrOwnCanton, "attacking canton %d", gm.ToCantonID)
+		}
+		if !gs.AreAdjacent(gm.FromCantonID, gm.ToCantonID) {
+			return reject(ErrNotAdjacent, "cantons %d and %d", gm.FromCantonID, gm.ToCantonID)
+		}
+		if gs.TroopCounts[gm.FromCantonID] <= 1 {
+			return reject(ErrInsufficientTroops, "attacking with %d troops", gs.TroopCounts[gm.FromCantonID])
+		}
+		if gs.Rules.AttackGranularity() == StopLossAttack && (gm.NumTroops <= 0 || gm.NumTroops >= gs.TroopCounts[gm.FromCantonID]) {
+			return reject(ErrInsufficientTroops, "committing %d of %d troops", gm.NumTroops, gs.TroopCounts[gm.FromCantonID])
+		}
+
+	case OccupyAction:
+		if (Occupation{From: gm.FromCantonID, To: gm.ToCantonID}) != gs.Occupation {
+			return reject(ErrNotConquered, "occupying canton %d from canton %d", gm.ToCantonID, gm.FromCantonID)
+		}
+		minTroops := gs.TroopCounts[gm.ToCantonID]
+		maxTroops := minTroops + gs.TroopCounts[gm.FromCantonID] - 1
+		if gm.NumTroops < minTroops || gm.NumTroops > maxTroops {
+			return reject(ErrInsufficientTroops, "occupying with %d troops, between %d and %d", gm.NumTroops, minTroops, maxTroops)
+		}
+
+	case DefendAction:
+		if gm.FromCantonID != gs.Battle.From || gm.ToCantonID != gs.Battle.To {
+			return reject(ErrNotAttacked, "defending canton %d from canton %d", gm.ToCantonID, gm.FromCantonID)
+		}
+		if gm.NumTroops <= 0 || gm.NumTroops > gs.maxDefendDice() {
+			return reject(ErrInvalidDice, "rolling %d of %d dice", gm.NumTroops, gs.maxDefendDice())
+		}
+
+	case ManeuverAction:
+		if err := owned(gm.FromCantonID); err != nil {
+			return err
+		}
+		if err := owned(gm.ToCantonID); err != nil {
+			return err
+		}
+		if gm.NumTroops <= 0 || gm.NumTroops >= gs.TroopCounts[gm.FromCantonID] {
+			return reject(ErrInsufficientTroops, "moving %d of %d troops", gm.NumTroops, gs.TroopCounts[gm.FromCantonID])
+		}
+		if gm.FromCantonID == gm.ToCantonID || !gs.AreConnected(gm.FromCantonID, gm.ToCantonID, gs.CurrentPlayer) {
+			return reject(ErrNotConnected, "cantons %d and %d", gm.FromCantonID, gm.ToCantonID)
+		}
+		if gs.Rules.FortifyRule() == AdjacentFortify && !gs.AreAdjacent(gm.FromCantonID, gm.ToCantonID) {
+			return reject(ErrNotAdjacent, "cantons %d and %d", gm.FromCantonID, gm.ToCantonID)
+		}
+		if gs.hasFortified(gm.FromCantonID) {
+			return reject(ErrFortified, "canton %d", gm.FromCantonID)
+		}
+	}
+	return nil
+}
+
+// TryPlay plays a move after validating it, returning an error explaining why
+// the move was rejected instead of panicking like Play
+func (gs GameState) TryPlay(move Move) (State, error) {
+	if err := gs.ValidateMove(move); err != nil {
+		return nil, err
+	}
+	return gs.Play(move), nil
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMove(t *testing.T) {
+	attack := func(from, to int) *GameMove {
+		return &GameMove{ActionType: AttackAction, FromCantonID: from, ToCantonID: to}
+	}
+	maneuver := func(from, to, troops int) *GameMove {
+		return &GameMove{ActionType: ManeuverAction, FromCantonID: from, ToCantonID: to, NumTroops: troops}
+	}
+	reinforce := func(to, troops int) *GameMove {
+		return &GameMove{ActionType: ReinforceAction, ToCantonID: to, NumTroops: troops}
+	}
+	pass := &GameMove{ActionType: PassAction}
+
+	cases := []struct {
+		name     string
+		setup    func(gs *GameState)
+		move     Move
+		expected error
+	}{
+		{"attack", nil, attack(22, 7), nil},
+		{"attack pass", nil, pass, nil},
+		{"attack from a foreign canton", nil, attack(2, 7), ErrNotOwned},
+		{"attack an own canton", func(gs *GameState) { gs.Ownership[7] = 1 }, attack(22, 7), ErrOwnCanton},
+		{"attack a distant canton", nil, attack(22, 20), ErrNotAdjacent},
+		{"attack an unknown canton", nil, attack(22, 99), ErrUnknownCanton},
+		{"attack with one troop", func(gs *GameState) { gs.TroopCounts[22] = 1 }, attack(22, 7), ErrInsufficientTroops},
+		{"maneuver while attacking", nil, maneuver(22, 7, 1), ErrWrongPhase},
+		{"maneuver", maneuverPhase, maneuver(22, 7, 999), nil},
+		{"maneuver every troop", maneuverPhase, maneuver(22, 7, 1000), ErrInsufficientTroops},
+		{"maneuver to a disconnected canton", func(gs *GameState) { maneuverPhase(gs); gs.Ownership[20] = 1 }, maneuver(22, 20, 1), ErrNotConnected},
+		{"maneuver to a foreign canton", maneuverPhase, maneuver(22, 2, 1), ErrNotOwned},
+		{"reinforce", reinforcementPhase, reinforce(22, 3), nil},
+		{"reinforce too many troops", reinforcementPhase, reinforce(22, 4), ErrInsufficientTroops},
+		{"reinforce a foreign canton", reinforcementPhase, reinforce(7, 1), ErrNotOwned},
+		{"pass while reinforcing", reinforcementPhase, pass, ErrWrongPhase},
+		{"trade an invalid set", reinforcementPhase, &GameMove{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 1, 2}}, ErrInvalidSet},
+		{"reinforce with a full hand", func(gs *GameState) {
+			reinforcementPhase(gs)
+			gs.PlayerHands[1] = []RiskCard{
+				{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Infantry, TerritoryID: 2},
+				{Type: Cavalry, TerritoryID: 3}, {Type: Cavalry, TerritoryID: 4},
+			}
+		}, reinforce(22, 1), ErrMustTrade},
+		{"play after the game is won", func(gs *GameState) { gs.Won = "Player1" }, pass, ErrGameOver},
+		{"play an unknown move", nil, otherMove{}, ErrUnknownMove},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gs := newEliminationState()
+			if c.setup != nil {
+				c.setup(gs)
+			}
+
+			err := gs.ValidateMove(c.move)
+
+			if c.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, c.expected)
+			var moveErr *MoveError
+			require.True(t, errors.As(err, &moveErr), "Should return a *MoveError")
+			require.Equal(t, c.move, moveErr.Move)
+		})
+	}
+}
+
+type otherMove struct{}
+
+func (otherMove) IsStochastic() bool { return false }
+
+func maneuverPhase(gs *GameState) {
+	gs.Phase = ManeuverPhase
+	gs.Ownership[7] = 1
+}
+
+func reinforcementPhase(gs *GameState) {
+	gs.Phase = ReinforcementPhase
+	gs.TroopsToPlace = 3
+}
+
+func TestTryPlay(t *testing.T) {
+	t.Run("rejects an invalid move without panicking", func(t *testing.T) {
+		gs := newEliminationState()
+
+		got, err := gs.TryPlay(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 20})
+
+		require.ErrorIs(t, err, ErrNotAdjacent)
+		require.Nil(t, got)
+	})
+
+	t.Run("plays a valid move", func(t *testing.T) {
+		gs := newEliminationState()
+
+		got, err := gs.TryPlay(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7})
+
+		require.NoError(t, err)
+		require.Equal(t, 1, got.(*GameState).Ownership[7])
+	})
+
+	t.Run("accepts every legal move", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		var state State = NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithRand(rng))
+		for i := 0; i < 3000 && state.Winner() == ""; i++ {
+			moves := state.LegalMoves()
+			for _, move := range moves {
+				require.NoError(t, state.(*GameState).ValidateMove(move))
+			}
+			state = state.Play(moves[rng.Intn(len(moves))])
+		}
+	})
+}
@@ -1,5 +1,21 @@
 package game
 
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed maps/*.yaml
+var mapFiles embed.FS
+
 type Canton struct {
 	ID           int    // Unique identifier for the canton
 	Name         string // Full name of the canton
@@ -17,6 +33,7 @@ type Region struct {
 
 // Map represents the game map, containing all the cantons and regions.
 type Map struct {
+	Name    string          // Name of the map
 	Cantons map[int]*Canton // Maps canton IDs to Canton pointers
 	Regions map[int]*Region // Maps region IDs to Region pointers
 }
@@ -54,124 +71,218 @@ func contains(slice []int, item int) bool {
 	return false
 }
 
-// CreateMap initializes the map with cantons, regions, and their adjacents.
+// CreateMap initializes the Swiss map with cantons, regions, and their adjacents.
 func CreateMap() *Map {
+	return mustLoadBuiltinMap("maps/switzerland.yaml")
+}
+
+// CreateClassicMap initializes the classic world map with 42 territories on 6 continents.
+func CreateClassicMap() *Map {
+	return mustLoadBuiltinMap("maps/classic.yaml")
+}
+
+// builtinMaps maps the names of the maps shipped with the game to their constructors
+var builtinMaps = map[string]func() *Map{
+	"classic":     CreateClassicMap,
+	"switzerland": CreateMap,
+}
+
+// BuiltinMap creates the built-in map with the given name.
+func BuiltinMap(name string) (*Map, error) {
+	create, ok := builtinMaps[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown built-in map %q", name)
+	}
+	return create(), nil
+}
+
+// BuiltinMapNames lists the names of the built-in maps.
+func BuiltinMapNames() []string {
+	return sortedKeys(builtinMaps)
+}
+
+// mustLoadBuiltinMap loads a map file embedded in the binary
+func mustLoadBuiltinMap(path string) *Map {
+	data, err := mapFiles.ReadFile(path)
+	if err != nil {
+		panic(fmt.Sprintf("failed to read built-in map %s: %v", path, err))
+	}
+	m, err := LoadMap(bytes.NewReader(data))
+	if err != nil {
+		panic(fmt.Sprintf("failed to load built-in map %s: %v", path, err))
+	}
+	return m
+}
+
+// mapDefinition is the declarative format of a map file
+type mapDefinition struct {
+	Name        string                `json:"name" yaml:"name"`
+	Regions     []regionDefinition    `json:"regions" yaml:"regions"`
+	Territories []territoryDefinition `json:"territories" yaml:"territories"`
+}
+
+type regionDefinition struct {
+	ID    int    `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Bonus int    `json:"bonus" yaml:"bonus"`
+}
+
+type territoryDefinition struct {
+	ID           int      `json:"id" yaml:"id"`
+	Name         string   `json:"name" yaml:"name"`
+	Abbreviation string   `json:"abbreviation" yaml:"abbreviation"`
+	Region       int      `json:"region" yaml:"region"`
+	Adjacent     []string `json:"adjacent" yaml:"adjacent"` // Abbreviations of adjacent territories
+}
+
+// LoadMap reads a map definition in JSON or YAML format. Territories are
+// identified by IDs 0 to n-1 and list their neighbors by abbreviation; borders
+// only need to be listed on one side.
+func LoadMap(r io.Reader) (*Map, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map definition: %w", err)
+	}
+
+	var def mapDefinition
+	if json.Valid(data) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&def)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode map definition: %w", err)
+	}
+
+	return def.build()
+}
+
+// LoadMapFile reads a map definition from a JSON or YAML file.
+func LoadMapFile(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open map file: %w", err)
+	}
+	defer f.Close()
+
+	return LoadMap(f)
+}
+
+// LoadBorderList reads a plain list of borders with one line per territory in
+// the form 'AG': 'BL', 'LU', 'ZG'. Borders are kept as listed, without regions,
+// so the list can be validated and compared against other map sources.
+func LoadBorderList(r io.Reader) (*Map, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read border list: %w", err)
+	}
+
 	m := NewMap()
+	idByAbbreviation := make(map[string]int)
+	cantonID := func(abbrev string) int {
+		id, ok := idByAbbreviation[abbrev]
+		if !ok {
+			id = len(idByAbbreviation)
+			idByAbbreviation[abbrev] = id
+			m.AddCanton(&Canton{ID: id, Name: abbrev, Abbreviation: abbrev, AdjacentIDs: []int{}, RegionID: -1})
+		}
+		return id
+	}
+	unquote := func(field string) string {
+		return strings.Trim(strings.TrimSpace(field), `'"`)
+	}
 
-	// Initialize regions
-	m.Regions = make(map[int]*Region)
-	for regionID, regionName := range regionNames {
-		m.Regions[regionID] = &Region{
-			ID:        regionID,
-			Name:      regionName,
-			CantonIDs: []int{},
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		abbrev, neighbors, ok := strings.Cut(line, ":")
+		if !ok || unquote(abbrev) == "" {
+			return nil, fmt.Errorf("line %d: expected 'territory': 'neighbor', ...", i+1)
+		}
+		id := cantonID(unquote(abbrev))
+		for _, neighbor := range strings.Split(neighbors, ",") {
+			if unquote(neighbor) == "" {
+				continue
+			}
+			neighborID := cantonID(unquote(neighbor))
+			if !contains(m.Cantons[id].AdjacentIDs, neighborID) {
+				m.Cantons[id].AdjacentIDs = append(m.Cantons[id].AdjacentIDs, neighborID)
+			}
 		}
 	}
+	return m, nil
+}
 
-	m.Regions[1].Bonus = 3
-	m.Regions[2].Bonus = 2
-	m.Regions[3].Bonus = 1
+func (def mapDefinition) build() (*Map, error) {
+	m := NewMap()
+	m.Name = def.Name
 
-	// Initialize cantons
-	for id, abbrev := range cantonAbbreviations {
-		regionID := 2 // default to German region
-		if val, ok := cantonRegionMap[abbrev]; ok {
-			regionID = val
+	for _, rd := range def.Regions {
+		if _, ok := m.Regions[rd.ID]; ok {
+			return nil, fmt.Errorf("duplicate region ID %d", rd.ID)
 		}
-		canton := &Canton{
-			ID:           id,
-			Name:         cantonNames[id],
-			Abbreviation: abbrev,
-			AdjacentIDs:  []int{},
-			RegionID:     regionID,
+		m.Regions[rd.ID] = &Region{
+			ID:        rd.ID,
+			Name:      rd.Name,
+			CantonIDs: []int{},
+			Bonus:     rd.Bonus,
 		}
-		m.AddCanton(canton)
-		m.Regions[regionID].CantonIDs = append(m.Regions[regionID].CantonIDs, canton.ID)
 	}
 
-	// Add borders between cantons
-	for abbrev, neighbors := range adjacencyData {
-		id1 := cantonIDMap[abbrev]
-		for _, neighborAbbrev := range neighbors {
-			id2 := cantonIDMap[neighborAbbrev]
-			m.AddBorder(id1, id2)
+	// Add territories in ID order so regions list their cantons in ID order
+	territories := make([]territoryDefinition, len(def.Territories))
+	copy(territories, def.Territories)
+	sort.SliceStable(territories, func(i, j int) bool {
+		return territories[i].ID < territories[j].ID
+	})
+
+	idByAbbreviation := make(map[string]int, len(territories))
+	for i, td := range territories {
+		if td.ID != i {
+			return nil, fmt.Errorf("territory IDs must run from 0 to %d, got %d", len(territories)-1, td.ID)
 		}
+		if _, ok := idByAbbreviation[td.Abbreviation]; ok {
+			return nil, fmt.Errorf("duplicate territory abbreviation %q", td.Abbreviation)
+		}
+		region, ok := m.Regions[td.Region]
+		if !ok {
+			return nil, fmt.Errorf("territory %q belongs to unknown region %d", td.Abbreviation, td.Region)
+		}
+		idByAbbreviation[td.Abbreviation] = td.ID
+
+		m.AddCanton(&Canton{
+			ID:           td.ID,
+			Name:         td.Name,
+			Abbreviation: td.Abbreviation,
+			AdjacentIDs:  []int{},
+			RegionID:     td.Region,
+		})
+		region.CantonIDs = append(region.CantonIDs, td.ID)
 	}
 
-	return m
-}
+	// Add borders between territories. Each territory first gets its neighbors
+	// in the listed order; borders listed on one side only are appended after.
+	for _, td := range territories {
+		for _, neighbor := range td.Adjacent {
+			neighborID, ok := idByAbbreviation[neighbor]
+			if !ok {
+				return nil, fmt.Errorf("territory %q borders unknown territory %q", td.Abbreviation, neighbor)
+			}
+			if !contains(m.Cantons[td.ID].AdjacentIDs, neighborID) {
+				m.Cantons[td.ID].AdjacentIDs = append(m.Cantons[td.ID].AdjacentIDs, neighborID)
+			}
+		}
+	}
+	for _, td := range territories {
+		for _, neighbor := range td.Adjacent {
+			m.AddBorder(td.ID, idByAbbreviation[neighbor])
+		}
+	}
 
-// REGION DATA
-var regionNames = map[int]string{
-	1: "French",
-	2: "German",
-	3: "Italian",
-}
-
-// Mapping of canton abbreviations to region IDs
-var cantonRegionMap = map[string]int{
-	// French-speaking cantons
-	"GE": 1,
-	"VD": 1,
-	"NE": 1,
-	"JU": 1,
-	"FR": 1,
-	"VS": 1,
-	// Italian-speaking canton
-	"TI": 3,
-}
-
-// List of canton abbreviations
-var cantonAbbreviations = []string{
-	"AG", "AI", "AR", "BE", "BL", "BS", "FR", "GE", "GL", "GR",
-	"JU", "LU", "NE", "NW", "OW", "SG", "SH", "SO", "SZ", "TG",
-	"TI", "UR", "VD", "VS", "ZG", "ZH",
-}
-
-// Corresponding canton names
-var cantonNames = []string{
-	"Aargau", "Appenzell Innerrhoden", "Appenzell Ausserrhoden", "Bern",
-	"Basel-Landschaft", "Basel-Stadt", "Fribourg", "Geneva", "Glarus",
-	"Graubünden", "Jura", "Lucerne", "Neuchâtel", "Nidwalden", "Obwalden",
-	"St. Gallen", "Schaffhausen", "Solothurn", "Schwyz", "Thurgau",
-	"Ticino", "Uri", "Vaud", "Valais", "Zug", "Zürich",
-}
-
-// Map of canton abbreviations to their IDs
-var cantonIDMap = map[string]int{
-	"AG": 0, "AI": 1, "AR": 2, "BE": 3, "BL": 4, "BS": 5,
-	"FR": 6, "GE": 7, "GL": 8, "GR": 9, "JU": 10, "LU": 11,
-	"NE": 12, "NW": 13, "OW": 14, "SG": 15, "SH": 16, "SO": 17,
-	"SZ": 18, "TG": 19, "TI": 20, "UR": 21, "VD": 22, "VS": 23,
-	"ZG": 24, "ZH": 25,
-}
-
-// Adjacency data: mapping of canton abbreviations to their neighboring cantons
-var adjacencyData = map[string][]string{
-	"AG": {"BL", "LU", "ZG", "ZH", "SO"},
-	"AI": {"AR", "SG"},
-	"AR": {"AI", "SG"},
-	"BE": {"FR", "JU", "NE", "SO", "VD", "VS", "LU"},
-	"BL": {"AG", "BS", "SO", "JU"},
-	"BS": {"BL"},
-	"FR": {"BE", "VD", "NE"},
-	"GE": {"VD"},
-	"GL": {"SG", "SZ", "GR"},
-	"GR": {"SG", "TI", "GL", "UR"},
-	"JU": {"BE", "SO", "BL"},
-	"LU": {"AG", "BE", "NW", "OW", "ZG"},
-	"NE": {"BE", "FR", "VD"},
-	"NW": {"OW", "LU", "UR"},
-	"OW": {"NW", "UR", "LU"},
-	"SG": {"AI", "AR", "GL", "TG", "ZH", "GR"},
-	"SH": {"ZH", "TG"},
-	"SO": {"BE", "BL", "JU", "AG"},
-	"SZ": {"ZG", "UR", "GL"},
-	"TG": {"SH", "SG", "ZH"},
-	"TI": {"GR", "VS", "UR"},
-	"UR": {"SZ", "OW", "GR", "TI", "NW"},
-	"VD": {"GE", "FR", "VS", "NE", "BE"},
-	"VS": {"VD", "BE", "TI", "UR"},
-	"ZG": {"AG", "SZ", "LU", "ZH"},
-	"ZH": {"AG", "SG", "TG", "SH", "ZG"},
+	return m, nil
 }
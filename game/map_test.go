@@ -0,0 +1,186 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMapYAML = `
+name: triangle
+regions:
+  - id: 1
+    name: North
+    bonus: 2
+  - id: 2
+    name: South
+    bonus: 1
+territories:
+  - id: 0
+    name: Alpha
+    abbreviation: A
+    region: 1
+    adjacent: [B]
+  - id: 1
+    name: Beta
+    abbreviation: B
+    region: 1
+    adjacent: [C]
+  - id: 2
+    name: Gamma
+    abbreviation: C
+    region: 2
+    adjacent: [A]
+`
+
+const testMapJSON = `{
+  "name": "triangle",
+  "regions": [
+    {"id": 1, "name": "North", "bonus": 2},
+    {"id": 2, "name": "South", "bonus": 1}
+  ],
+  "territories": [
+    {"id": 0, "name": "Alpha", "abbreviation": "A", "region": 1, "adjacent": ["B"]},
+    {"id": 1, "name": "Beta", "abbreviation": "B", "region": 1, "adjacent": ["C"]},
+    {"id": 2, "name": "Gamma", "abbreviation": "C", "region": 2, "adjacent": ["A"]}
+  ]
+}`
+
+func TestLoadMap(t *testing.T) {
+	t.Run("loads a YAML definition", func(t *testing.T) {
+		m, err := LoadMap(strings.NewReader(testMapYAML))
+
+		require.NoError(t, err)
+		require.Equal(t, "triangle", m.Name)
+		require.Len(t, m.Cantons, 3)
+		require.Equal(t, "Beta", m.Cantons[1].Name)
+		require.Equal(t, []int{0, 1}, m.Regions[1].CantonIDs)
+		require.Equal(t, 2, m.Regions[1].Bonus)
+		require.ElementsMatch(t, []int{1, 2}, m.Cantons[0].AdjacentIDs, "Borders should be added on both sides")
+	})
+
+	t.Run("loads the same map from JSON", func(t *testing.T) {
+		fromYAML, err := LoadMap(strings.NewReader(testMapYAML))
+		require.NoError(t, err)
+		fromJSON, err := LoadMap(strings.NewReader(testMapJSON))
+		require.NoError(t, err)
+
+		require.Equal(t, fromYAML, fromJSON)
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		cases := map[string]string{
+			"unknown region":   strings.Replace(testMapYAML, "region: 2", "region: 3", 1),
+			"unknown neighbor": strings.Replace(testMapYAML, "adjacent: [A]", "adjacent: [D]", 1),
+			"duplicate abbrev": strings.Replace(testMapYAML, "abbreviation: C", "abbreviation: A", 1),
+			"non-dense IDs":    strings.Replace(testMapYAML, "id: 2\n    name: Gamma", "id: 5\n    name: Gamma", 1),
+			"unknown field":    strings.Replace(testMapYAML, "bonus: 1", "bonus: 1\n    color: red", 1),
+		}
+		for name, definition := range cases {
+			_, err := LoadMap(strings.NewReader(definition))
+			require.Error(t, err, name)
+		}
+	})
+}
+
+func TestCreateMap(t *testing.T) {
+	m := CreateMap()
+
+	require.NoError(t, m.Validate())
+	require.Equal(t, "switzerland", m.Name)
+
+	// Cantons in ID order with their neighbors in the order CreateMap has always listed them
+	cantons := []struct {
+		name      string
+		abbrev    string
+		region    int
+		neighbors []string
+	}{
+		{"Aargau", "AG", 2, []string{"BL", "LU", "ZG", "ZH", "SO"}},
+		{"Appenzell Innerrhoden", "AI", 2, []string{"AR", "SG"}},
+		{"Appenzell Ausserrhoden", "AR", 2, []string{"AI", "SG"}},
+		{"Bern", "BE", 2, []string{"FR", "JU", "NE", "SO", "VD", "VS", "LU"}},
+		{"Basel-Landschaft", "BL", 2, []string{"AG", "BS", "SO", "JU"}},
+		{"Basel-Stadt", "BS", 2, []string{"BL"}},
+		{"Fribourg", "FR", 1, []string{"BE", "VD", "NE"}},
+		{"Geneva", "GE", 1, []string{"VD"}},
+		{"Glarus", "GL", 2, []string{"SG", "SZ", "GR"}},
+		{"Graubünden", "GR", 2, []string{"SG", "TI", "GL", "UR"}},
+		{"Jura", "JU", 1, []string{"BE", "SO", "BL"}},
+		{"Lucerne", "LU", 2, []string{"AG", "BE", "NW", "OW", "ZG"}},
+		{"Neuchâtel", "NE", 1, []string{"BE", "FR", "VD"}},
+		{"Nidwalden", "NW", 2, []string{"OW", "LU", "UR"}},
+		{"Obwalden", "OW", 2, []string{"NW", "UR", "LU"}},
+		{"St. Gallen", "SG", 2, []string{"AI", "AR", "GL", "TG", "ZH", "GR"}},
+		{"Schaffhausen", "SH", 2, []string{"ZH", "TG"}},
+		{"Solothurn", "SO", 2, []string{"BE", "BL", "JU", "AG"}},
+		{"Schwyz", "SZ", 2, []string{"ZG", "UR", "GL"}},
+		{"Thurgau", "TG", 2, []string{"SH", "SG", "ZH"}},
+		{"Ticino", "TI", 3, []string{"GR", "VS", "UR"}},
+		{"Uri", "UR", 2, []string{"SZ", "OW", "GR", "TI", "NW", "VS"}},
+		{"Vaud", "VD", 1, []string{"GE", "FR", "VS", "NE", "BE"}},
+		{"Valais", "VS", 1, []string{"VD", "BE", "TI", "UR"}},
+		{"Zug", "ZG", 2, []string{"AG", "SZ", "LU", "ZH"}},
+		{"Zürich", "ZH", 2, []string{"AG", "SG", "TG", "SH", "ZG"}},
+	}
+	idByAbbreviation := make(map[string]int, len(cantons))
+	for id, c := range cantons {
+		idByAbbreviation[c.abbrev] = id
+	}
+	require.Len(t, m.Cantons, len(cantons))
+	for id, c := range cantons {
+		adjacentIDs := make([]int, len(c.neighbors))
+		for i, neighbor := range c.neighbors {
+			adjacentIDs[i] = idByAbbreviation[neighbor]
+		}
+		require.Equal(t, &Canton{
+			ID:           id,
+			Name:         c.name,
+			Abbreviation: c.abbrev,
+			AdjacentIDs:  adjacentIDs,
+			RegionID:     c.region,
+		}, m.Cantons[id], c.abbrev)
+	}
+
+	require.Equal(t, map[int]*Region{
+		1: {ID: 1, Name: "French", Bonus: 3, CantonIDs: []int{6, 7, 10, 12, 22, 23}},
+		2: {ID: 2, Name: "German", Bonus: 2, CantonIDs: []int{0, 1, 2, 3, 4, 5, 8, 9, 11, 13, 14, 15, 16, 17, 18, 19, 21, 24, 25}},
+		3: {ID: 3, Name: "Italian", Bonus: 1, CantonIDs: []int{20}},
+	}, m.Regions)
+}
+
+func TestCreateClassicMap(t *testing.T) {
+	m := CreateClassicMap()
+
+	require.NoError(t, m.Validate())
+	require.Equal(t, "classic", m.Name)
+	require.Len(t, m.Cantons, 42)
+	require.Len(t, m.Regions, 6)
+	require.Len(t, borders(m), 83)
+
+	bonuses := map[string]int{"North America": 5, "South America": 2, "Europe": 5, "Africa": 3, "Asia": 7, "Australia": 2}
+	sizes := map[string]int{"North America": 9, "South America": 4, "Europe": 7, "Africa": 6, "Asia": 12, "Australia": 4}
+	for _, region := range m.Regions {
+		require.Equal(t, bonuses[region.Name], region.Bonus, "%s bonus", region.Name)
+		require.Len(t, region.CantonIDs, sizes[region.Name], "%s territories", region.Name)
+	}
+
+	cantons := cantonsByAbbreviation(m)
+	require.Contains(t, cantons["AK"].AdjacentIDs, cantons["KAM"].ID, "Alaska borders Kamchatka across the Bering Strait")
+	require.Contains(t, cantons["BR"].AdjacentIDs, cantons["NAF"].ID, "Brazil borders North Africa")
+	require.ElementsMatch(t, []int{cantons["NG"].ID, cantons["WAU"].ID}, cantons["EAU"].AdjacentIDs)
+}
+
+func TestBuiltinMap(t *testing.T) {
+	require.Equal(t, []string{"classic", "switzerland"}, BuiltinMapNames())
+	for _, name := range BuiltinMapNames() {
+		m, err := BuiltinMap(name)
+		require.NoError(t, err)
+		require.Equal(t, name, m.Name)
+		require.NoError(t, m.Validate(), name)
+	}
+
+	_, err := BuiltinMap("atlantis")
+	require.Error(t, err)
+}
@@ -8,7 +8,8 @@ type Move interface {
 
 const (
 	BONUS_TROOPS_REGION = 1
-	//maybe could add number of players here? or in the rules. I'm not sure.
+	MinPlayers          = 2 // Minimum number of players in a game
+	MaxPlayers          = 6 // Maximum number of players in a game
 )
 
 type StateHash uint64
@@ -22,6 +23,19 @@ type State interface {
 	Winner() string
 }
 
+// Rollout plays a game out in place, applying and undoing moves without copying
+// the state, for the many moves of a simulation that need not be kept
+type Rollout interface {
+	Player() string
+	LegalMoves() []Move // Valid till the next call to LegalMoves
+	Apply(Move)
+	Undo() // Undoes the last move applied
+	Winner() string
+	State() State      // View of the current state, valid till the next move
+	Reset(state State) // Starts over from a copy of the state, reusing memory
+}
+
 // Evaluates the game state to a score between -1 and 1 indicating how
-// favorable the current player's position is to a winning (positive) outcome.
+// favorable the position of the player to move (see State.Player) is to a
+// winning (positive) outcome.
 type Evaluate func(State) float64
@@ -5,9 +5,31 @@ type GameMove struct {
 	ActionType   ActionType
 	FromCantonID int
 	ToCantonID   int
-	NumTroops    int
+	NumTroops    int          // Troops placed, moved or committed to an attack, or dice defended with
+	CardIndices  [SetSize]int // Indices of the cards traded in from the player's hand
 }
 
+// IsStochastic tells whether the outcome of the move may depend on dice: attacks
+// roll at least once whatever the attack granularity of the rules, unless the
+// defender chooses its dice, when the dice are rolled by its defense instead.
+// Moves do not know the rules, so GameState.IsStochastic tells exactly.
 func (gm GameMove) IsStochastic() bool {
-	return gm.ActionType == AttackAction
+	return gm.ActionType == AttackAction || gm.ActionType == DefendAction
+}
+
+// IsStochastic tells whether the outcome of a move of the state depends on
+// dice under its rules. Attacks declared for the defender to choose its dice
+// roll none, so they lead to a single state.
+func (gs *GameState) IsStochastic(move Move) bool {
+	gm, ok := move.(*GameMove)
+	if !ok {
+		return move.IsStochastic()
+	}
+	switch gm.ActionType {
+	case AttackAction:
+		return !gs.Rules.ChooseDefenderDice()
+	case DefendAction:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,56 @@
+package game
+
+import "fmt"
+
+// Occupation is a canton just conquered by the current player along with the
+// canton it was attacked from, whose troops may advance into it
+type Occupation struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// occupyMoves generates a move for each number of troops the conquered canton
+// can be occupied with: the troops already moved in for the dice rolled, half
+// the troops that can advance, or all but one troop of the attacking canton
+func (gs *GameState) occupyMoves(buf *buffers) {
+	from, to := gs.Occupation.From, gs.Occupation.To
+	minTroops := gs.TroopCounts[to]
+	maxTroops := minTroops + gs.TroopCounts[from] - 1
+
+	previous := 0
+	for _, numTroops := range [...]int{minTroops, maxTroops / 2, maxTroops} {
+		if numTroops <= previous {
+			continue
+		}
+		previous = numTroops
+		buf.moves = append(buf.moves, GameMove{
+			ActionType:   OccupyAction,
+			FromCantonID: from,
+			ToCantonID:   to,
+			NumTroops:    numTroops,
+		})
+	}
+}
+
+// occupy leaves the given number of troops in the conquered canton, advancing
+// them from the attacking canton, and resumes the attack. A conqueror holding
+// the cards of an eliminated player trades them in first.
+func (gs *GameState) occupy(fromID, toID, numTroops int) error {
+	if (Occupation{From: fromID, To: toID}) != gs.Occupation {
+		return fmt.Errorf("cannot occupy: canton %d was not conquered from canton %d", toID, fromID)
+	}
+	advance := numTroops - gs.TroopCounts[toID]
+	if advance < 0 || advance >= gs.TroopCounts[fromID] {
+		return fmt.Errorf("cannot occupy: %d troops out of %d", numTroops, gs.TroopCounts[toID]+gs.TroopCounts[fromID]-1)
+	}
+
+	gs.setTroops(fromID, gs.TroopCounts[fromID]-advance)
+	gs.setTroops(toID, numTroops)
+	gs.setOccupation(Occupation{})
+	if len(gs.PlayerHands[gs.CurrentPlayer]) >= EliminationTradeHandSize {
+		gs.setPhase(ReinforcementPhase)
+	} else {
+		gs.setPhase(AttackPhase)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOccupation(t *testing.T) {
+	occupationRules := func() *StandardRules {
+		rules := NewStandardRules()
+		rules.Occupation = true
+		return rules
+	}
+	newOccupationState := func() *GameState {
+		gs := newEliminationState()
+		gs.Rules = occupationRules()
+		gs.Ownership[0] = 2 // Player 2 survives losing GE
+		return gs
+	}
+	attack := &GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}
+
+	t.Run("moves all but one troop in by default", func(t *testing.T) {
+		gs := newEliminationState()
+
+		got := gs.Play(attack).(*GameState)
+
+		require.Equal(t, AttackPhase, got.Phase)
+		require.Equal(t, 1, got.TroopCounts[22], "Should leave a single troop behind")
+		require.Zero(t, got.Occupation)
+	})
+
+	t.Run("moves in the dice rolled and lets the attacker choose the rest", func(t *testing.T) {
+		gs := newOccupationState()
+
+		got := gs.Play(attack).(*GameState)
+
+		require.Equal(t, OccupyPhase, got.Phase)
+		require.Equal(t, Occupation{From: 22, To: 7}, got.Occupation)
+		require.Equal(t, 3, got.TroopCounts[7], "Should move in as many troops as dice rolled")
+		total := got.TroopCounts[7] + got.TroopCounts[22] - 1
+		occupy := func(numTroops int) Move {
+			return &GameMove{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 7, NumTroops: numTroops}
+		}
+		require.Equal(t, []Move{occupy(3), occupy(total / 2), occupy(total)}, got.LegalMoves(), "Should offer the minimum, half and all but one troop")
+
+		got = got.Play(occupy(total / 2)).(*GameState)
+
+		require.Equal(t, AttackPhase, got.Phase, "Should resume the attack")
+		require.Zero(t, got.Occupation)
+		require.Equal(t, total/2, got.TroopCounts[7])
+		require.Equal(t, total-total/2+1, got.TroopCounts[22])
+	})
+
+	t.Run("skips the choice when no more troops can advance", func(t *testing.T) {
+		gs := newOccupationState()
+		gs.TroopCounts[22] = 2
+
+		got := gs.Play(attack).(*GameState)
+		for i := 0; i < 100 && got.Ownership[7] != 1; i++ { // Roll till the single attacker wins
+			got = gs.Play(attack).(*GameState)
+		}
+
+		require.Equal(t, 1, got.Ownership[7])
+		require.Equal(t, AttackPhase, got.Phase)
+		require.Equal(t, 1, got.TroopCounts[7])
+	})
+
+	t.Run("validates occupations", func(t *testing.T) {
+		got := newOccupationState().Play(attack).(*GameState)
+		total := got.TroopCounts[7] + got.TroopCounts[22] - 1
+
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 0, NumTroops: 3}), ErrNotConquered)
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 7, NumTroops: 2}), ErrInsufficientTroops)
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 7, NumTroops: total + 1}), ErrInsufficientTroops)
+		require.ErrorIs(t, got.ValidateMove(&GameMove{ActionType: PassAction}), ErrWrongPhase)
+		require.ErrorIs(t, got.ValidateMove(attack), ErrWrongPhase)
+		require.NoError(t, got.ValidateMove(&GameMove{ActionType: OccupyAction, FromCantonID: 22, ToCantonID: 7, NumTroops: total}))
+	})
+
+	t.Run("trades in the cards of an eliminated player after occupying", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.Rules = occupationRules()
+		gs.PlayerHands[1] = []RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Cavalry, TerritoryID: 2}}
+		gs.PlayerHands[2] = []RiskCard{{Type: Infantry, TerritoryID: 3}, {Type: Cavalry, TerritoryID: 4}, {Type: Artillery, TerritoryID: 5}}
+
+		got := gs.Play(attack).(*GameState)
+
+		require.Equal(t, []int{2}, got.Eliminated)
+		require.Equal(t, OccupyPhase, got.Phase, "Should occupy the canton first")
+
+		got = got.Play(got.LegalMoves()[0]).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase, "Should trade in the cards received")
+	})
+
+	t.Run("plays random games with occupations", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rng := rand.New(rand.NewSource(1))
+		gs := NewGameState(CreateMap(), occupationRules(), WithPlayers(3), WithSeed(1))
+		var state State = gs
+		r := NewGameState(CreateMap(), occupationRules(), WithPlayers(3), WithSeed(1)).Rollout()
+		start := r.State().Hash()
+		played, occupations := 0, 0
+		for ; played < 3000 && state.Winner() == ""; played++ {
+			moves := state.LegalMoves()
+			for _, move := range moves {
+				require.NoError(t, state.(*GameState).ValidateMove(move))
+			}
+			if state.(*GameState).Phase == OccupyPhase {
+				occupations++
+			}
+			index := rng.Intn(len(moves))
+			state = state.Play(moves[index])
+			r.Apply(r.LegalMoves()[index])
+			require.Equal(t, state.Hash(), r.State().Hash(), "Should occupy the same way in place")
+		}
+		require.Positive(t, occupations, "Should choose how to occupy conquered cantons")
+
+		for i := 0; i < played; i++ {
+			r.Undo()
+		}
+		require.Equal(t, start, r.State().Hash(), "Should undo the occupations")
+	})
+
+	t.Run("encodes the pending occupation", func(t *testing.T) {
+		gs := newOccupationState().Play(attack).(*GameState)
+		gs.rng = nil
+
+		data, err := json.Marshal(gs)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(data, &got))
+		got.owned, got.copied = gs.owned, gs.copied // Whether memory is shared with other states is not encoded
+
+		require.Equal(t, *gs, got)
+		require.Equal(t, gs.Hash(), got.Hash())
+		require.Equal(t, gs.LegalMoves(), got.LegalMoves())
+	})
+}
@@ -0,0 +1,41 @@
+package game
+
+import "math/rand"
+
+// Rand is the source of randomness of a game, used to deal the cantons, pick
+// the starting player, shuffle the deck and roll the dice. *rand.Rand
+// satisfies it, so a game seeded with rand.New(rand.NewSource(seed)) can be
+// replayed move by move.
+type Rand interface {
+	Intn(n int) int
+	Shuffle(n int, swap func(i, j int))
+}
+
+// globalRand draws from the shared, goroutine-safe source of math/rand
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int {
+	return rand.Intn(n)
+}
+
+func (globalRand) Shuffle(n int, swap func(i, j int)) {
+	rand.Shuffle(n, swap)
+}
+
+// random returns the source of randomness of the game, falling back to the
+// shared source for states built without one
+func (gs *GameState) random() Rand {
+	if gs.rng == nil {
+		return globalRand{}
+	}
+	return gs.rng
+}
+
+// UseRand returns a copy of the state drawing from the given source of
+// randomness. Searches use it to explore the game without consuming the random
+// numbers of the actual game, which would make it impossible to replay.
+func (gs GameState) UseRand(rng Rand) State {
+	newGs := gs.Copy()
+	newGs.rng = rng
+	return &newGs
+}
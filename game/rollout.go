@@ -0,0 +1,273 @@
+package game
+
+import "maps"
+
+// buffers holds the memory that generating and playing moves reuses, so that a
+// rollout plays moves in place without allocating
+type buffers struct {
+	moves      []GameMove          // Legal moves, pointed to by legal
+	legal      []Move              // Legal moves returned to the caller
+	sets       [][SetSize]RiskCard // Distinct sets of cards that can be traded in
+	marks      []int               // Marks per canton of graph searches
+	queue      []int               // Queue of graph searches
+	attackDice []int
+	defendDice []int
+}
+
+// buffer returns the buffers of a rollout, or new buffers for immutable states
+// whose moves must outlive the next call
+func (gs *GameState) buffer() *buffers {
+	if gs.scratch == nil {
+		return &buffers{}
+	}
+	return gs.scratch
+}
+
+// legalMoves points to the moves generated into the buffers
+func (buf *buffers) legalMoves() []Move {
+	if len(buf.moves) == 0 {
+		return nil
+	}
+	legal := buf.legal[:0]
+	for i := range buf.moves {
+		legal = append(legal, &buf.moves[i])
+	}
+	buf.legal = legal
+	return legal
+}
+
+// resize returns a slice of length n, reusing the memory of s if large enough
+func resize[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// changeKind identifies the field of the state a change was made to
+type changeKind uint8
+
+const (
+	currentPlayerChange changeKind = iota
+	phaseChange
+	troopsChange
+	ownerChange
+	troopsToPlaceChange
+	playerTroopsChange
+	exchangesChange
+	conqueredChange
+	occupationChange
+	battleChange
+	maneuversChange
+	fortifiedChange
+	wonChange
+	lastMoveChange
+	handChange      // A player's hand was replaced or added to
+	handCardsChange // The cards of a player's hand were overwritten in place
+	deckChange
+	discardedChange
+	eliminatedChange
+)
+
+// change records the previous value of a field of the state
+type change struct {
+	kind   changeKind
+	index  int        // Canton or player whose field changed, or canton an occupation was attacked from
+	value  int        // Previous value of an int or bool field, offset of the saved cards of a hand, or conquered canton
+	cards  []RiskCard // Previous slice of cards
+	ints   []int      // Previous slice of eliminated players or fortified cantons
+	move   Move       // Previous last move
+	won    string     // Previous winner
+	battle Battle     // Previous pending battle
+}
+
+// journal records the changes moves make to a state played in place, so they
+// can be undone
+type journal struct {
+	changes []change
+	cards   []RiskCard // Cards of hands overwritten in place
+}
+
+// record journals a change if the state is played in place
+func (gs *GameState) record(c change) {
+	if gs.journal != nil {
+		gs.journal.changes = append(gs.journal.changes, c)
+	}
+}
+
+// ownHand returns the hand of a player for trading in cards in place. Rollouts
+// save the cards of the hand so the trade can be undone, while immutable states
+// get a copy since they share hands with the state they were copied from.
+func (gs *GameState) ownHand(playerID int) []RiskCard {
+	hand := gs.PlayerHands[playerID]
+	if gs.journal == nil {
+		return append([]RiskCard(nil), hand...)
+	}
+	gs.record(change{kind: handCardsChange, index: playerID, value: len(gs.journal.cards)})
+	gs.journal.cards = append(gs.journal.cards, hand...)
+	return hand
+}
+
+// undo reverts the changes made since the journal had the given length
+func (j *journal) undo(gs *GameState, length int) {
+	for i := len(j.changes) - 1; i >= length; i-- {
+		c := j.changes[i]
+		switch c.kind {
+		case currentPlayerChange:
+			gs.CurrentPlayer = c.value
+		case phaseChange:
+			gs.Phase = Phase(c.value)
+		case troopsChange:
+			gs.TroopCounts[c.index] = c.value
+		case ownerChange:
+			gs.Ownership[c.index] = c.value
+		case troopsToPlaceChange:
+			gs.TroopsToPlace = c.value
+		case playerTroopsChange:
+			gs.PlayerTroops[c.index] = c.value
+		case exchangesChange:
+			gs.Exchanges = c.value
+		case conqueredChange:
+			gs.ConqueredThisTurn = c.value != 0
+		case occupationChange:
+			gs.Occupation = Occupation{From: c.index, To: c.value}
+		case battleChange:
+			gs.Battle = c.battle
+		case maneuversChange:
+			gs.Maneuvers = c.value
+		case wonChange:
+			gs.Won = c.won
+		case lastMoveChange:
+			gs.LastMove = c.move
+		case handChange:
+			gs.PlayerHands[c.index] = c.cards
+		case handCardsChange:
+			saved := j.cards[c.value:]
+			gs.PlayerHands[c.index] = gs.PlayerHands[c.index][:len(saved)]
+			copy(gs.PlayerHands[c.index], saved)
+			j.cards = j.cards[:c.value]
+		case deckChange:
+			gs.Cards = c.cards
+		case discardedChange:
+			gs.DiscardedCards = c.cards
+		case eliminatedChange:
+			gs.Eliminated = c.ints
+		case fortifiedChange:
+			gs.Fortified = c.ints
+		}
+	}
+	clear(j.changes[length:]) // Release the moves and cards referenced
+	j.changes = j.changes[:length]
+}
+
+// gameRollout plays a GameState out in place, journaling the changes of each
+// move so they can be undone. The slices of the state are copied into memory
+// owned by the rollout, with room for every card in every hand, so that moves
+// neither touch the states they were reset from nor allocate.
+type gameRollout struct {
+	gs      GameState
+	journal journal
+	scratch buffers
+	moves   []int // Length of the journal before each move
+
+	// Memory owned by the rollout, reused on reset
+	troops, owners, eliminated, fortified []int
+	playerTroops                          map[int]int
+	hands                                 [][]RiskCard // Memory of each hand
+	handSlots                             [][]RiskCard // Hands of the state, which moves may replace
+	deck, discarded                       []RiskCard
+}
+
+// Rollout returns a copy of the state that plays moves in place
+func (gs GameState) Rollout() Rollout {
+	r := &gameRollout{}
+	r.Reset(&gs)
+	return r
+}
+
+// Reset copies a *GameState into the rollout, reusing its memory
+func (r *gameRollout) Reset(state State) {
+	gs, ok := state.(*GameState)
+	if !ok {
+		panic("unexpected state type")
+	}
+	if gs == &r.gs { // Keep the current state, forgetting the moves applied
+		r.resetJournal()
+		return
+	}
+	src := *gs
+	numCards := len(src.Cards) + len(src.DiscardedCards)
+	for _, hand := range src.PlayerHands {
+		numCards += len(hand)
+	}
+
+	r.gs = src
+	if r.playerTroops == nil {
+		r.playerTroops = make(map[int]int, len(src.PlayerTroops))
+	}
+	clear(r.playerTroops)
+	maps.Copy(r.playerTroops, src.PlayerTroops)
+	r.troops = append(r.troops[:0], src.TroopCounts...)
+	r.owners = append(r.owners[:0], src.Ownership...)
+	r.eliminated = append(resize(r.eliminated, src.NumPlayers)[:0], src.Eliminated...)
+	r.fortified = append(resize(r.fortified, len(src.TroopCounts))[:0], src.Fortified...)
+	r.deck = append(resize(r.deck, numCards)[:0], src.Cards...)
+	r.discarded = append(resize(r.discarded, numCards)[:0], src.DiscardedCards...)
+	r.hands = resize(r.hands, len(src.PlayerHands))
+	r.handSlots = resize(r.handSlots, len(src.PlayerHands))
+	for playerID, hand := range src.PlayerHands {
+		r.hands[playerID] = append(resize(r.hands[playerID], numCards)[:0], hand...)
+		r.handSlots[playerID] = r.hands[playerID]
+	}
+
+	r.gs.TroopCounts = r.troops
+	r.gs.Ownership = r.owners
+	r.gs.PlayerTroops = r.playerTroops
+	r.gs.Eliminated = r.eliminated
+	r.gs.Fortified = r.fortified
+	r.gs.Cards = r.deck
+	r.gs.DiscardedCards = r.discarded
+	r.gs.PlayerHands = r.handSlots
+	r.gs.hashed = false                          // Rollouts are not hashed
+	r.gs.owned, r.gs.copied = allComponents, nil // Copies of rollouts share no memory
+	r.gs.journal = &r.journal
+	r.gs.scratch = &r.scratch
+	r.resetJournal()
+}
+
+func (r *gameRollout) resetJournal() {
+	clear(r.journal.changes)
+	r.journal.changes = r.journal.changes[:0]
+	r.journal.cards = r.journal.cards[:0]
+	r.moves = r.moves[:0]
+}
+
+func (r *gameRollout) Player() string {
+	return r.gs.Player()
+}
+
+func (r *gameRollout) LegalMoves() []Move {
+	return r.gs.generateMoves(&r.scratch)
+}
+
+func (r *gameRollout) Apply(move Move) {
+	r.moves = append(r.moves, len(r.journal.changes))
+	r.gs.apply(move.(*GameMove))
+}
+
+func (r *gameRollout) Undo() {
+	if len(r.moves) == 0 {
+		panic("no move to undo")
+	}
+	last := len(r.moves) - 1
+	r.journal.undo(&r.gs, r.moves[last])
+	r.moves = r.moves[:last]
+}
+
+func (r *gameRollout) Winner() string {
+	return r.gs.Winner()
+}
+
+func (r *gameRollout) State() State {
+	return &r.gs
+}
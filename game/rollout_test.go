@@ -0,0 +1,129 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollout(t *testing.T) {
+	t.Run("plays moves like Play", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		for numPlayers := MinPlayers; numPlayers <= MaxPlayers; numPlayers++ {
+			var state State = NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(numPlayers), WithSeed(int64(numPlayers)))
+			r := NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(numPlayers), WithSeed(int64(numPlayers))).Rollout()
+			for i := 0; i < 5000 && state.Winner() == ""; i++ {
+				moves := state.LegalMoves()
+				require.Equal(t, moves, r.LegalMoves())
+
+				index := rng.Intn(len(moves))
+				state = state.Play(moves[index])
+				r.Apply(r.LegalMoves()[index])
+
+				require.Equal(t, state.Hash(), r.State().Hash())
+				require.Equal(t, discarded(state), discarded(r.State()))
+				require.Equal(t, state.Winner(), r.Winner())
+				require.Equal(t, state.Player(), r.Player())
+			}
+		}
+	})
+
+	t.Run("undoes moves", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(2))
+		gs := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithSeed(2))
+		r := gs.Rollout()
+		var played []GameState
+		for i := 0; i < 5000 && r.Winner() == ""; i++ {
+			played = append(played, r.State().(*GameState).Copy())
+			moves := r.LegalMoves()
+			r.Apply(moves[rng.Intn(len(moves))])
+		}
+
+		for i := len(played) - 1; i >= 0; i-- {
+			r.Undo()
+			got := r.State().(*GameState)
+			require.Equal(t, played[i].Hash(), got.Hash(), "Should restore the state before move %d", i)
+			require.Equal(t, discarded(&played[i]), discarded(got))
+			require.Equal(t, played[i].LastMove, got.LastMove)
+		}
+		require.Panics(t, r.Undo, "Should not undo past the start")
+	})
+
+	t.Run("leaves the state it starts from untouched", func(t *testing.T) {
+		gs := playedState(t, CreateMap())
+		before, err := json.Marshal(gs) // Copies share memory with the state
+		require.NoError(t, err)
+		r := gs.Rollout()
+		for i := 0; i < 500 && r.Winner() == ""; i++ {
+			r.Apply(r.LegalMoves()[0])
+		}
+
+		after, err := json.Marshal(gs)
+		require.NoError(t, err)
+		require.JSONEq(t, string(before), string(after))
+	})
+
+	t.Run("resets to another state", func(t *testing.T) {
+		r := NewGameState(CreateMap(), NewStandardRules(), WithSeed(4)).Rollout()
+		for i := 0; i < 100 && r.Winner() == ""; i++ {
+			r.Apply(r.LegalMoves()[0])
+		}
+		gs := playedState(t, CreateMap())
+
+		r.Reset(gs)
+
+		require.Equal(t, gs.Hash(), r.State().Hash())
+		require.Equal(t, gs.LegalMoves(), r.LegalMoves())
+		require.Panics(t, r.Undo, "Should forget the moves applied before the reset")
+	})
+
+	t.Run("applies and undoes moves without allocating", func(t *testing.T) {
+		r := NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(3), WithSeed(5)).Rollout()
+		for _, phase := range []Phase{ReinforcementPhase, AttackPhase, ManeuverPhase} {
+			require.Equal(t, phase, r.State().(*GameState).Phase)
+			allocs := testing.AllocsPerRun(100, func() {
+				moves := r.LegalMoves()
+				r.Apply(moves[0])
+				r.Undo()
+			})
+			require.Zero(t, allocs, "Should not allocate in phase %d", phase)
+
+			moves := r.LegalMoves()
+			r.Apply(moves[len(moves)-1]) // Place every troop or pass
+		}
+	})
+}
+
+// discarded returns the discarded cards of a state, which the hash does not
+// keep the order of, telling no discarded cards and an empty pile alike
+func discarded(state State) []RiskCard {
+	return append([]RiskCard{}, state.(*GameState).DiscardedCards...)
+}
+
+func BenchmarkRollout(b *testing.B) {
+	gs := NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(3), WithSeed(1))
+
+	b.Run("copy", func(b *testing.B) {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < b.N; i++ {
+			var state State = gs
+			for state.Winner() == "" {
+				moves := state.LegalMoves()
+				state = state.Play(moves[rng.Intn(len(moves))])
+			}
+		}
+	})
+	b.Run("in place", func(b *testing.B) {
+		rng := rand.New(rand.NewSource(1))
+		r := gs.Rollout()
+		for i := 0; i < b.N; i++ {
+			r.Reset(gs)
+			for r.Winner() == "" {
+				moves := r.LegalMoves()
+				r.Apply(moves[rng.Intn(len(moves))])
+			}
+		}
+	})
+}
@@ -1,9 +1,50 @@
 package game
 
+// AttackGranularity sets how much of a battle a single attack move resolves
+type AttackGranularity int
+
+const (
+	BlitzAttack      AttackGranularity = iota // Fight till either side runs out of troops
+	SingleRollAttack                          // Roll the dice once
+	StopLossAttack                            // Fight till the troops the attacker committed are lost
+)
+
+// FortifyRule sets between which cantons maneuvers may move troops, the number
+// of maneuvers per turn being set by Rules.MaxManeuvers
+type FortifyRule int
+
+const (
+	ConnectedFortify FortifyRule = iota // Between cantons connected through the player's cantons
+	AdjacentFortify                     // Between adjacent cantons only
+	SourceFortify                       // Between connected cantons, out of each canton once per turn
+)
+
+// UnlimitedManeuvers lets players maneuver as often as they wish, up to as many
+// maneuvers per turn as there are cantons on the map, see Rules.MaxManeuvers
+const UnlimitedManeuvers = -1
+
 type Rules interface {
 	MaxAttackTroops() int
 	MaxDefendTroops() int
 	DetermineAttackOutcome(attackerRolls, defenderRolls []int) (attackerLosses, defenderLosses int)
 	IsAttackSuccessful(attackerRolls, defenderRolls []int) bool
-	// TODO add more ruels
+	// ChooseOccupation tells whether attackers choose how many troops occupy a
+	// conquered canton, rather than moving in all but one troop
+	ChooseOccupation() bool
+	// AttackGranularity tells how much of a battle an attack move resolves
+	AttackGranularity() AttackGranularity
+	// ChooseDefenderDice tells whether defenders choose how many dice to roll,
+	// rather than always rolling as many as they can
+	ChooseDefenderDice() bool
+	// Reinforcements tells how many troops a player holding the given number of
+	// cantons receives at the start of its turn, before region bonuses
+	Reinforcements(numCantons int) int
+	// ExchangeArmies tells how many armies trading in a set of cards awards as
+	// the given exchange of the game, counting from 1
+	ExchangeArmies(exchange int, set [SetSize]RiskCard) int
+	// MaxManeuvers tells how many maneuvers a player may make per turn,
+	// unlimited if negative, see UnlimitedManeuvers
+	MaxManeuvers() int
+	// FortifyRule tells between which cantons maneuvers may move troops
+	FortifyRule() FortifyRule
 }
@@ -0,0 +1,80 @@
+package game
+
+import "slices"
+
+// classicArmies are the armies each player starts with in the classic game, by
+// number of players
+var classicArmies = [MaxPlayers + 1]int{2: 40, 3: 35, 4: 30, 5: 25, 6: 20}
+
+// StartingArmies returns the armies each player places during the setup phase:
+// as many as in the classic game, but at least enough for every player to
+// claim an equal share of the cantons
+func StartingArmies(numPlayers, numCantons int) int {
+	return max(classicArmies[numPlayers], (numCantons+numPlayers-1)/numPlayers)
+}
+
+// claimMoves generates a move for each unowned canton the current player can claim
+func (gs *GameState) claimMoves(buf *buffers) {
+	for cantonID, owner := range gs.Ownership {
+		if owner < 0 {
+			buf.moves = append(buf.moves, GameMove{
+				ActionType: ClaimAction,
+				ToCantonID: cantonID,
+			})
+		}
+	}
+}
+
+// placementMoves generates a move placing a single army on each canton of the
+// current player that borders an enemy, like reinforcements
+func (gs *GameState) placementMoves(buf *buffers) {
+	for cantonID, owner := range gs.Ownership {
+		if owner == gs.CurrentPlayer && gs.bordersEnemy(cantonID) {
+			buf.moves = append(buf.moves, GameMove{
+				ActionType: ReinforceAction,
+				ToCantonID: cantonID,
+				NumTroops:  1,
+			})
+		}
+	}
+}
+
+// claim takes an unowned canton with one of the current player's armies
+func (gs *GameState) claim(cantonID int) {
+	gs.setOwner(cantonID, gs.CurrentPlayer)
+	gs.setTroops(cantonID, 1)
+	gs.setPlayerTroops(gs.CurrentPlayer, gs.PlayerTroops[gs.CurrentPlayer]-1)
+	gs.endSetupTurn()
+}
+
+// place puts armies of the current player on one of its cantons
+func (gs *GameState) place(cantonID, armies int) {
+	gs.setTroops(cantonID, gs.TroopCounts[cantonID]+armies)
+	gs.setPlayerTroops(gs.CurrentPlayer, gs.PlayerTroops[gs.CurrentPlayer]-armies)
+	if gs.PlayerTroops[gs.CurrentPlayer] < 0 {
+		panic("PlayerTroops cannot be negative")
+	}
+	gs.endSetupTurn()
+}
+
+// endSetupTurn passes the turn on to the next player with armies left, moving
+// on to placing armies once every canton is claimed. Once every army is placed,
+// the game starts with the player after the one who placed the last army.
+func (gs *GameState) endSetupTurn() {
+	if gs.Phase == ClaimPhase && !slices.Contains(gs.Ownership, -1) {
+		gs.setPhase(PlacementPhase)
+	}
+
+	playerID := gs.CurrentPlayer
+	for i := 0; i < gs.NumPlayers; i++ {
+		playerID = playerID%gs.NumPlayers + 1
+		if gs.PlayerTroops[playerID] > 0 {
+			gs.setCurrentPlayer(playerID)
+			return
+		}
+	}
+
+	gs.setPhase(ReinforcementPhase)
+	gs.setCurrentPlayer(gs.NextPlayer())
+	gs.calculateTroopsToPlace()
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupPhase(t *testing.T) {
+	newSetupState := func() *GameState {
+		return NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithSetupPhase(), WithSeed(1))
+	}
+
+	t.Run("starts with every canton to claim", func(t *testing.T) {
+		gs := newSetupState()
+
+		require.Equal(t, ClaimPhase, gs.Phase)
+		for _, owner := range gs.Ownership {
+			require.Equal(t, -1, owner, "Should leave every canton unowned")
+		}
+		require.Equal(t, map[int]int{1: 35, 2: 35, 3: 35}, gs.PlayerTroops)
+		require.Len(t, gs.LegalMoves(), len(gs.Map.Cantons), "Should offer to claim every canton")
+	})
+
+	t.Run("takes turns claiming cantons then placing armies", func(t *testing.T) {
+		gs := newSetupState()
+		first := gs.CurrentPlayer
+
+		var state State = gs
+		for i := 0; i < len(gs.Map.Cantons); i++ {
+			require.Equal(t, (first+i-1)%3+1, state.(*GameState).CurrentPlayer, "Should take turns claiming")
+			require.Empty(t, state.Winner(), "Should not win by claiming the first canton")
+			state = state.Play(state.LegalMoves()[0])
+		}
+		require.Equal(t, PlacementPhase, state.(*GameState).Phase, "Should place armies once every canton is claimed")
+
+		for state.(*GameState).Phase == PlacementPhase {
+			state = state.Play(state.LegalMoves()[0])
+		}
+		gs = state.(*GameState)
+		troops := map[int]int{}
+		for cantonID, owner := range gs.Ownership {
+			troops[owner] += gs.TroopCounts[cantonID]
+		}
+		require.Equal(t, map[int]int{1: 35, 2: 35, 3: 35}, troops, "Should place every starting army")
+		require.Equal(t, ReinforcementPhase, gs.Phase)
+		require.Equal(t, first, gs.CurrentPlayer, "Should start the game with the player who claimed first")
+		require.Positive(t, gs.TroopsToPlace)
+	})
+
+	t.Run("validates setup moves", func(t *testing.T) {
+		gs := newSetupState()
+		claimed := gs.Play(&GameMove{ActionType: ClaimAction, ToCantonID: 7}).(*GameState)
+
+		require.ErrorIs(t, claimed.ValidateMove(&GameMove{ActionType: ClaimAction, ToCantonID: 7}), ErrClaimed)
+		require.ErrorIs(t, claimed.ValidateMove(&GameMove{ActionType: ClaimAction, ToCantonID: 99}), ErrUnknownCanton)
+		require.ErrorIs(t, claimed.ValidateMove(&GameMove{ActionType: ReinforceAction, ToCantonID: 7, NumTroops: 1}), ErrWrongPhase)
+		require.ErrorIs(t, claimed.ValidateMove(&GameMove{ActionType: PassAction}), ErrWrongPhase)
+
+		claimed.Phase = PlacementPhase
+		claimed.CurrentPlayer = gs.CurrentPlayer
+		require.NoError(t, claimed.ValidateMove(&GameMove{ActionType: ReinforceAction, ToCantonID: 7, NumTroops: 34}))
+		require.ErrorIs(t, claimed.ValidateMove(&GameMove{ActionType: ReinforceAction, ToCantonID: 7, NumTroops: 35}), ErrInsufficientTroops)
+	})
+
+	t.Run("plays random games through the setup", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rng := rand.New(rand.NewSource(2))
+		for numPlayers := MinPlayers; numPlayers <= MaxPlayers; numPlayers++ {
+			gs := NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(numPlayers), WithSetupPhase(), WithRand(rng))
+			var state State = gs
+			r := gs.Rollout()
+			played := 0
+			for ; state.(*GameState).Phase >= ClaimPhase; played++ {
+				moves := state.LegalMoves()
+				for _, move := range moves {
+					require.NoError(t, state.(*GameState).ValidateMove(move))
+				}
+				index := rng.Intn(len(moves))
+				state = state.Play(moves[index])
+				r.Apply(r.LegalMoves()[index])
+			}
+			require.Equal(t, state.Hash(), r.State().Hash(), "Should set up the same game in place")
+
+			for i := 0; i < played; i++ {
+				r.Undo()
+			}
+			require.Equal(t, gs.Hash(), r.State().Hash(), "Should undo the setup")
+		}
+	})
+
+	t.Run("encodes the armies left to place", func(t *testing.T) {
+		gs := newSetupState()
+		next := gs.Play(gs.LegalMoves()[0]).(*GameState)
+
+		data, err := json.Marshal(next)
+		require.NoError(t, err)
+		var got GameState
+		require.NoError(t, json.Unmarshal(data, &got))
+
+		require.Equal(t, next.PlayerTroops, got.PlayerTroops)
+		require.Equal(t, next.Hash(), got.Hash())
+	})
+}
+
+func TestStartingArmies(t *testing.T) {
+	require.Equal(t, 40, StartingArmies(2, 42), "Should deal the classic armies")
+	require.Equal(t, 20, StartingArmies(6, 42), "Should deal the classic armies")
+	require.Equal(t, 22, StartingArmies(6, 128), "Should deal enough armies to claim a share of a large map")
+}
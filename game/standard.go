@@ -1,10 +1,26 @@
 package game
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const standardRulesType = "standard" // Type of StandardRules in JSON
+
 type StandardRules struct {
-	MaxAttackDice int
-	MaxDefendDice int
+	MaxAttackDice int               `json:"maxAttackDice"`
+	MaxDefendDice int               `json:"maxDefendDice"`
+	Occupation    bool              `json:"occupation,omitempty"`   // Whether attackers choose how many troops occupy a conquered canton
+	Attack        AttackGranularity `json:"attack,omitempty"`       // How much of a battle an attack move resolves, blitz by default
+	DefenderDice  bool              `json:"defenderDice,omitempty"` // Whether defenders choose how many dice to roll
+	Maneuvers     int               `json:"maneuvers,omitempty"`    // Maneuvers allowed per turn, one if zero and unlimited if negative
+	Fortify       FortifyRule       `json:"fortify,omitempty"`      // Cantons maneuvers may move troops between, connected ones by default
 }
 
+// standardFields has the fields of StandardRules without their methods, for
+// encoding them along with a type
+type standardFields StandardRules
+
 func NewStandardRules() *StandardRules {
 	return &StandardRules{
 		MaxAttackDice: 3,
@@ -12,6 +28,29 @@ func NewStandardRules() *StandardRules {
 	}
 }
 
+// MarshalJSON tags the rules with their type so they can be decoded into the Rules interface
+func (sr *StandardRules) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*standardFields
+	}{Type: standardRulesType, standardFields: (*standardFields)(sr)})
+}
+
+// validate range checks the dice and enums of the rules, which attacks and
+// maneuvers would otherwise loop on or ignore
+func (sr *StandardRules) validate() error {
+	if sr.MaxAttackDice < 1 || sr.MaxDefendDice < 1 {
+		return fmt.Errorf("need at least a die per side, got %d attack and %d defend dice", sr.MaxAttackDice, sr.MaxDefendDice)
+	}
+	if sr.Attack < BlitzAttack || sr.Attack > StopLossAttack {
+		return fmt.Errorf("invalid attack granularity %d", sr.Attack)
+	}
+	if sr.Fortify < ConnectedFortify || sr.Fortify > SourceFortify {
+		return fmt.Errorf("invalid fortify rule %d", sr.Fortify)
+	}
+	return nil
+}
+
 func (sr *StandardRules) MaxAttackTroops() int {
 	return sr.MaxAttackDice
 }
@@ -33,8 +72,66 @@ func (sr *StandardRules) MaxDefendTroops() int {
 	return sr.MaxDefendDice
 }
 
+// IsAttackSuccessful tells whether a roll favors the attacker, the defender
+// losing more troops than the attacker
 func (sr *StandardRules) IsAttackSuccessful(attackerRolls, defenderRolls []int) bool {
-	// Todo
+	attackerLosses, defenderLosses := sr.DetermineAttackOutcome(attackerRolls, defenderRolls)
+	return defenderLosses > attackerLosses
+}
+
+func (sr *StandardRules) ChooseOccupation() bool {
+	return sr.Occupation
+}
+
+func (sr *StandardRules) AttackGranularity() AttackGranularity {
+	return sr.Attack
+}
+
+func (sr *StandardRules) ChooseDefenderDice() bool {
+	return sr.DefenderDice
+}
+
+// Reinforcements awards a troop per three cantons held, and at least three
+func (sr *StandardRules) Reinforcements(numCantons int) int {
+	return max(3, numCantons/3)
+}
+
+// ExchangeArmies awards armies whatever the cards of the set, depending on the
+// number of sets traded in so far:
+// The first set traded in - 4 armies
+// The second set - 6 armies
+// The third set - 8 armies
+// The fourth set - 10 armies
+// The fifth set - 12 armies
+// The sixth set - 15 armies
+// After the sixth set, each additional set is worth 5 more than the previous.
+func (sr *StandardRules) ExchangeArmies(exchange int, set [SetSize]RiskCard) int {
+	switch exchange {
+	case 1:
+		return 4
+	case 2:
+		return 6
+	case 3:
+		return 8
+	case 4:
+		return 10
+	case 5:
+		return 12
+	case 6:
+		return 15
+	default:
+		// After sixth, it's 15 + 5*(exchange-6)
+		return 15 + 5*(exchange-6)
+	}
+}
+
+func (sr *StandardRules) MaxManeuvers() int {
+	if sr.Maneuvers == 0 {
+		return 1
+	}
+	return sr.Maneuvers
+}
 
-	return false
+func (sr *StandardRules) FortifyRule() FortifyRule {
+	return sr.Fortify
 }
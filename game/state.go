@@ -1,11 +1,12 @@
 package game
 
 import (
-	"encoding/binary"
 	"fmt"
-	"hash/fnv"
+	"maps"
 	"math/rand"
+	"slices"
 	"sort"
+	"sync/atomic"
 )
 
 type Phase int
@@ -15,6 +16,10 @@ const (
 	AttackPhase
 	ManeuverPhase
 	EndPhase
+	ClaimPhase     // Setup phase in which players take turns claiming unowned cantons
+	PlacementPhase // Setup phase in which players take turns placing their remaining armies
+	OccupyPhase    // Attack phase in which the attacker chooses how many troops occupy a conquered canton
+	DefendPhase    // Attack phase in which the defender chooses how many dice to roll
 )
 
 // GameState represents the dynamic state of the game at any point. stuff that will change during the game, (everything except the map - which is static), and at some point even the rules.
@@ -23,6 +28,7 @@ type GameState struct {
 	TroopCounts       []int        // Troop counts per canton, indexed by canton ID
 	Ownership         []int        // Owner IDs per canton, indexed by canton ID (-1 indicates unowned)
 	Rules             Rules        // The set of game rules to apply
+	NumPlayers        int          // Number of players, identified by IDs 1 to NumPlayers
 	CurrentPlayer     int          // The current player
 	LastMove          Move         // The last move made (for delta)
 	Phase             Phase        // The current phase of the game
@@ -30,20 +36,105 @@ type GameState struct {
 	TroopsToPlace     int          // Troops to place during reinforcement phase
 	Cards             []RiskCard   // Card deck
 	DiscardedCards    []RiskCard   // Discarded cards
-	PlayerHands       [][]RiskCard // Player hands
+	PlayerHands       [][]RiskCard // Player hands, indexed by player ID
 	Exchanges         int          // Number of exchanges
 	ConqueredThisTurn bool         // Whether a territory was conquered this turn
+	Eliminated        []int        // Player IDs in the order they were eliminated
 	Won               string       // The player winner of the game, "" if no winner yet
+	Occupation        Occupation   // Canton to occupy during OccupyPhase, zero otherwise
+	Battle            Battle       // Attack awaiting the defender's dice during DefendPhase, zero otherwise
+	Maneuvers         int          // Maneuvers made this turn, reset when the turn ends
+	Fortified         []int        // Cantons troops were maneuvered out of this turn under the SourceFortify rule
+
+	rng     Rand         // Source of randomness, shared by all states of a game
+	hash    StateHash    // Zobrist hash kept up to date by moves, see hash.go
+	hashed  bool         // Whether hash is up to date, false till the first move
+	owned   component    // Components not shared with other states, see Copy
+	copied  *atomic.Bool // Set once the state is copied, when it stops owning its components
+	journal *journal     // Changes to undo when played in place by a rollout, see rollout.go
+	scratch *buffers     // Memory reused across the moves of a rollout
+}
+
+// setup holds the configuration of a new game
+type setup struct {
+	numPlayers int
+	wildCards  int
+	rng        Rand
+	setupPhase bool
+}
+
+// Option configures the game setup of a new GameState
+type Option func(s *setup)
+
+// WithPlayers sets the number of players taking part in the game
+func WithPlayers(numPlayers int) Option {
+	return func(s *setup) {
+		s.numPlayers = numPlayers
+	}
+}
+
+// WithWildCards sets the number of wild cards shuffled into the deck
+func WithWildCards(wildCards int) Option {
+	return func(s *setup) {
+		if wildCards >= 0 {
+			s.wildCards = wildCards
+		}
+	}
+}
+
+// WithRand sets the source of randomness of the game, the shared source of
+// math/rand by default
+func WithRand(rng Rand) Option {
+	return func(s *setup) {
+		if rng != nil {
+			s.rng = rng
+		}
+	}
+}
+
+// WithSetupPhase starts the game with players taking turns claiming the cantons
+// and then placing their starting armies one at a time, instead of dealing the
+// cantons at random with 3 troops each
+func WithSetupPhase() Option {
+	return func(s *setup) {
+		s.setupPhase = true
+	}
+}
+
+// WithSeed seeds the source of randomness of the game so it can be replayed
+func WithSeed(seed int64) Option {
+	return func(s *setup) {
+		s.rng = rand.New(rand.NewSource(seed))
+	}
 }
 
 // NewGameState initializes and returns a new GameState.
-func NewGameState(m *Map, rules Rules) *GameState {
+func NewGameState(m *Map, rules Rules, options ...Option) *GameState {
+	s := setup{ // Default values
+		numPlayers: MinPlayers,
+		wildCards:  DefaultWildCards,
+	}
+	for _, option := range options {
+		option(&s)
+	}
+
 	numCantons := len(m.Cantons)
+	if s.numPlayers < MinPlayers || s.numPlayers > MaxPlayers {
+		panic(fmt.Sprintf("number of players must be between %d and %d, got %d", MinPlayers, MaxPlayers, s.numPlayers))
+	}
+	if s.numPlayers > numCantons {
+		panic(fmt.Sprintf("not enough cantons (%d) for %d players", numCantons, s.numPlayers))
+	}
+
 	gs := &GameState{
 		Map:         m,
 		TroopCounts: make([]int, numCantons),
 		Ownership:   make([]int, numCantons),
 		Rules:       rules,
+		NumPlayers:  s.numPlayers,
+		rng:         s.rng,
+		owned:       allComponents,
+		copied:      new(atomic.Bool),
 	}
 
 	// Initialize all cantons to unowned
@@ -51,22 +142,24 @@ func NewGameState(m *Map, rules Rules) *GameState {
 		gs.Ownership[i] = -1
 	}
 
-	numPlayers := 2
+	numPlayers := gs.NumPlayers
 	STARTING_UNITS := 3 // or any other number
 
-	cantonIDs := make([]int, numCantons)
-	for i := 0; i < numCantons; i++ {
-		cantonIDs[i] = i
-	}
-	rand.Shuffle(numCantons, func(i, j int) {
-		cantonIDs[i], cantonIDs[j] = cantonIDs[j], cantonIDs[i]
-	})
+	if !s.setupPhase { // Otherwise players claim the cantons themselves
+		cantonIDs := make([]int, numCantons)
+		for i := 0; i < numCantons; i++ {
+			cantonIDs[i] = i
+		}
+		gs.random().Shuffle(numCantons, func(i, j int) {
+			cantonIDs[i], cantonIDs[j] = cantonIDs[j], cantonIDs[i]
+		})
 
-	// Assign territories in round-robin fashion to players as per Jean's code
-	for i, cid := range cantonIDs {
-		ownerID := (i % numPlayers) + 1
-		gs.Ownership[cid] = ownerID
-		gs.TroopCounts[cid] = STARTING_UNITS
+		// Assign territories in round-robin fashion to players as per Jean's code
+		for i, cid := range cantonIDs {
+			ownerID := (i % numPlayers) + 1
+			gs.Ownership[cid] = ownerID
+			gs.TroopCounts[cid] = STARTING_UNITS
+		}
 	}
 
 	// for i, owner := range gs.Ownership {
@@ -76,126 +169,172 @@ func NewGameState(m *Map, rules Rules) *GameState {
 	// fmt.Printf("[NewGameState] Done assigning. Now calling calculateTroopsToPlace() for Player %d\n",
 	// 	gs.CurrentPlayer)
 
-	gs.InitCards()
+	gs.InitCards(s.wildCards)
 	// Randomize starting player
-	gs.CurrentPlayer = rand.Intn(numPlayers) + 1
-	gs.PlayerHands = make([][]RiskCard, 3)
-	gs.PlayerHands[1] = []RiskCard{}
-	gs.PlayerHands[2] = []RiskCard{}
+	gs.CurrentPlayer = gs.random().Intn(numPlayers) + 1
+	gs.PlayerHands = make([][]RiskCard, numPlayers+1) // Index 0 is unused
+	for playerID := 1; playerID <= numPlayers; playerID++ {
+		gs.PlayerHands[playerID] = []RiskCard{}
+	}
 
+	if s.setupPhase {
+		gs.Phase = ClaimPhase
+		gs.PlayerTroops = make(map[int]int, numPlayers)
+		for playerID := 1; playerID <= numPlayers; playerID++ {
+			gs.PlayerTroops[playerID] = StartingArmies(numPlayers, numCantons)
+		}
+		return gs
+	}
 	gs.Phase = ReinforcementPhase
-	gs = gs.calculateTroopsToPlace()
+	gs.calculateTroopsToPlace()
 	return gs
 }
 
+// Copy returns a copy of the state that shares its slices and maps with the
+// state till either changes them: moves copy a component of the state before
+// changing it in place for the first time, so that the many states of a search
+// only hold the troops, hands and cards their moves changed. The copy owns no
+// component, and the state stops owning its own once it sees it was copied, so
+// the methods of either copy shared components before changing them. Copying
+// only sets an atomic flag of the state, so states can be copied and played
+// concurrently, but fields set directly are still shared.
 func (gs GameState) Copy() GameState {
-	troopCountsCopy := make([]int, len(gs.TroopCounts))
-	copy(troopCountsCopy, gs.TroopCounts)
+	newGs := gs
+	newGs.owned, newGs.copied = 0, nil
+	newGs.journal, newGs.scratch = nil, nil
+	if gs.journal != nil { // Rollouts keep changing their memory in place
+		newGs.unshare(allComponents)
+		newGs.Cards = slices.Clone(gs.Cards)
+	} else if gs.copied != nil && !gs.copied.Load() {
+		gs.copied.Store(true)
+	}
+	return newGs
+}
 
-	ownershipCopy := make([]int, len(gs.Ownership))
-	copy(ownershipCopy, gs.Ownership)
+// component identifies a part of the state that copies share till changed
+type component uint32
 
-	playerTroopsCopy := make(map[int]int)
-	for k, v := range gs.PlayerTroops {
-		playerTroopsCopy[k] = v
-	}
+const (
+	troopsComponent component = 1 << iota
+	ownersComponent
+	discardedComponent
+	eliminatedComponent
+	playerTroopsComponent
+	fortifiedComponent
+	handsComponent     // The slice of hands, whose hands are components of their own
+	firstHandComponent // The hand of player 0, followed by the hands of the other players
+)
 
-	playerHandsCopy := make([][]RiskCard, len(gs.PlayerHands))
-	for i, hand := range gs.PlayerHands {
-		handCopy := make([]RiskCard, len(hand))
-		copy(handCopy, hand)
-		playerHandsCopy[i] = handCopy
-	}
+// allComponents covers every component of a state, up to the hand of the last player
+const allComponents = firstHandComponent<<(MaxPlayers+1) - 1
 
-	cardsCopy := make([]RiskCard, len(gs.Cards))
-	copy(cardsCopy, gs.Cards)
+func handComponent(playerID int) component {
+	return firstHandComponent << playerID
+}
 
-	discardedCardsCopy := make([]RiskCard, len(gs.DiscardedCards))
-	copy(discardedCardsCopy, gs.DiscardedCards)
+// unshare copies the given components of the state not owned by it yet so that
+// they can be changed in place. The deck is never changed in place, so it is
+// always shared.
+func (gs *GameState) unshare(components component) {
+	if gs.copied != nil && gs.copied.Load() { // Copies share what the state owned so far
+		gs.owned, gs.copied = 0, nil
+	}
+	shared := components &^ gs.owned
+	if shared == 0 {
+		return
+	}
+	if gs.owned == 0 && gs.journal == nil {
+		gs.copied = new(atomic.Bool)
+	}
+	if shared&troopsComponent != 0 {
+		gs.TroopCounts = slices.Clone(gs.TroopCounts)
+	}
+	if shared&ownersComponent != 0 {
+		gs.Ownership = slices.Clone(gs.Ownership)
+	}
+	if shared&discardedComponent != 0 { // Room for the cards of a trade
+		gs.DiscardedCards = append(make([]RiskCard, 0, len(gs.DiscardedCards)+SetSize), gs.DiscardedCards...)
+	}
+	if shared&eliminatedComponent != 0 {
+		gs.Eliminated = append(make([]int, 0, gs.NumPlayers), gs.Eliminated...)
+	}
+	if shared&fortifiedComponent != 0 {
+		gs.Fortified = append(make([]int, 0, len(gs.TroopCounts)), gs.Fortified...)
+	}
+	if shared&playerTroopsComponent != 0 {
+		playerTroops := make(map[int]int, len(gs.PlayerTroops))
+		maps.Copy(playerTroops, gs.PlayerTroops)
+		gs.PlayerTroops = playerTroops
+	}
+	if shared&^(handsComponent-1) != 0 {
+		gs.unshareHands(shared)
+	}
+	gs.owned |= shared
+}
 
-	return GameState{
-		Map:               gs.Map,
-		TroopCounts:       troopCountsCopy,
-		Ownership:         ownershipCopy,
-		Rules:             gs.Rules,
-		CurrentPlayer:     gs.CurrentPlayer,
-		LastMove:          gs.LastMove,
-		Phase:             gs.Phase,
-		PlayerTroops:      playerTroopsCopy,
-		TroopsToPlace:     gs.TroopsToPlace,
-		Cards:             cardsCopy,
-		DiscardedCards:    discardedCardsCopy,
-		PlayerHands:       playerHandsCopy,
-		Exchanges:         gs.Exchanges,
-		ConqueredThisTurn: gs.ConqueredThisTurn,
-		Won:               gs.Won,
+func (gs *GameState) unshareHands(shared component) {
+	if gs.owned&handsComponent == 0 {
+		gs.PlayerHands = slices.Clone(gs.PlayerHands)
+		gs.owned |= handsComponent
+	}
+	for playerID, hand := range gs.PlayerHands {
+		if shared&handComponent(playerID) != 0 { // Room for a card drawn
+			gs.PlayerHands[playerID] = append(make([]RiskCard, 0, len(hand)+1), hand...)
+		}
 	}
 }
 
-func (gs GameState) InitCards() {
+// InitCards builds a shuffled deck with one card per canton, cycling through
+// the card types, plus the given number of wild cards
+func (gs *GameState) InitCards(wildCards int) {
 	types := []CardType{Infantry, Cavalry, Artillery}
-	tIndex := 0
-	for i := 0; i < 24; i++ {
-		gs.Cards = append(gs.Cards, RiskCard{Type: types[i%3], TerritoryID: i})
-		tIndex++
+	numCantons := len(gs.Map.Cantons)
+	deck := make([]RiskCard, 0, numCantons+wildCards)
+	for id := 0; id < numCantons; id++ {
+		deck = append(deck, RiskCard{Type: types[id%len(types)], TerritoryID: id})
+	}
+	for i := 0; i < wildCards; i++ {
+		deck = append(deck, RiskCard{Type: Wild, TerritoryID: -1})
 	}
-	// 2 Wild cards
-	gs.Cards = append(gs.Cards, RiskCard{Type: Wild, TerritoryID: -1})
-	gs.Cards = append(gs.Cards, RiskCard{Type: Wild, TerritoryID: -1})
 
 	// Shuffle the deck
-	rand.Shuffle(len(gs.Cards), func(i, j int) {
-		gs.Cards[i], gs.Cards[j] = gs.Cards[j], gs.Cards[i]
+	gs.random().Shuffle(len(deck), func(i, j int) {
+		deck[i], deck[j] = deck[j], deck[i]
 	})
+	gs.setDeck(deck)
+	gs.setDiscarded([]RiskCard{})
 }
 
-func (gs GameState) DrawCard() (RiskCard, bool) {
+// DrawCard takes the top card off the deck, reshuffling the discarded cards
+// into a new deck when it runs out
+func (gs *GameState) DrawCard() (RiskCard, bool) {
 	if len(gs.Cards) == 0 {
 		// If no cards left, reshuffle discarded into deck
 		if len(gs.DiscardedCards) == 0 {
 			// No cards at all
 			return RiskCard{}, false
 		}
-		gs.Cards = append(gs.Cards, gs.DiscardedCards...)
-		gs.DiscardedCards = nil
-		rand.Shuffle(len(gs.Cards), func(i, j int) {
-			gs.Cards[i], gs.Cards[j] = gs.Cards[j], gs.Cards[i]
+		deck := make([]RiskCard, len(gs.DiscardedCards))
+		copy(deck, gs.DiscardedCards)
+		gs.random().Shuffle(len(deck), func(i, j int) {
+			deck[i], deck[j] = deck[j], deck[i]
 		})
+		gs.setDeck(deck)
+		gs.setDiscarded(nil)
 	}
-	card := gs.Cards[0]
-	gs.Cards = gs.Cards[1:]
-	return card, true
+	return gs.drawTop(), true
 }
 
-func (gs GameState) AwardCardIfEligible() GameState {
-	newGs := gs.Copy()
-	if newGs.ConqueredThisTurn {
-		card, ok := newGs.DrawCard()
+// AwardCardIfEligible hands the current player a card at the end of a turn in
+// which it conquered at least one canton
+func (gs *GameState) AwardCardIfEligible() {
+	if gs.ConqueredThisTurn {
+		card, ok := gs.DrawCard()
 		if ok {
-			newGs.PlayerHands[newGs.CurrentPlayer] = append(newGs.PlayerHands[newGs.CurrentPlayer], card)
+			gs.addToHand(gs.CurrentPlayer, card)
 		}
 	}
-	newGs.ConqueredThisTurn = false // Reset for next turn
-	return newGs
-}
-
-func (gs *GameState) HandleCardTrading() {
-	playerID := gs.CurrentPlayer
-	hand := gs.PlayerHands[playerID]
-
-	// If player has 5 or more cards, they MUST trade in.
-	// Otherwise, they may (but we are implementing a must trade sets if available logic)
-	for {
-		if len(hand) < 3 {
-			break
-		}
-		set := gs.FindSet(hand)
-		if set == nil {
-			break
-		}
-		hand = gs.TradeInSet(hand, set)
-	}
-	gs.PlayerHands[playerID] = hand
+	gs.setConquered(false) // Reset for next turn
 }
 
 // Find a set of cards in the player's hand. We return the indices of the chosen set.
@@ -251,33 +390,55 @@ func (gs GameState) FindSet(hand []RiskCard) []int {
 	return nil
 }
 
+// canTrade checks whether the cards at the given hand indices are distinct and form a set
+func (gs GameState) canTrade(hand []RiskCard, indices [SetSize]int) bool {
+	var set [SetSize]RiskCard
+	for i, index := range indices {
+		if index < 0 || index >= len(hand) {
+			return false
+		}
+		for _, other := range indices[:i] {
+			if index == other {
+				return false
+			}
+		}
+		set[i] = hand[index]
+	}
+	return IsSet(set)
+}
+
 // Trade in a given set of cards, remove from hand, put into discard, increment gs.Exchanges, and give armies.
-func (gs GameState) TradeInSet(hand []RiskCard, setIndices []int) []RiskCard {
+// The hand is changed in place, so it must not be a hand shared with other states.
+func (gs *GameState) TradeInSet(hand []RiskCard, setIndices []int) []RiskCard {
 	playerID := gs.CurrentPlayer
 	// Extract the cards
 	var set []RiskCard
-	sort.Sort(sort.Reverse(sort.IntSlice(setIndices)))
+	slices.SortFunc(setIndices, func(a, b int) int { return b - a })
 	for _, idx := range setIndices {
 		set = append(set, hand[idx])
 		hand = append(hand[:idx], hand[idx+1:]...)
 	}
 
 	// Move set to discarded
-	gs.DiscardedCards = append(gs.DiscardedCards, set...)
+	for _, card := range set {
+		gs.discard(card)
+	}
 
 	// Increment exchanges count (global)
-	gs.Exchanges++
+	gs.setExchanges(gs.Exchanges + 1)
 
 	// Calculate how many armies
-	armiesFromSet := gs.ArmiesForThisExchange(gs.Exchanges)
-	gs.TroopsToPlace += armiesFromSet
+	var cards [SetSize]RiskCard
+	copy(cards[:], set)
+	armiesFromSet := gs.Rules.ExchangeArmies(gs.Exchanges, cards)
+	gs.setTroopsToPlace(gs.TroopsToPlace + armiesFromSet)
 
 	// Check territory bonus
 	extraArmiesGranted := 0
 	for _, card := range set {
 		if card.TerritoryID >= 0 && gs.Ownership[card.TerritoryID] == playerID && extraArmiesGranted < 2 {
 			// Place 2 extra armies on this territory
-			gs.TroopCounts[card.TerritoryID] += 2
+			gs.setTroops(card.TerritoryID, gs.TroopCounts[card.TerritoryID]+2)
 			extraArmiesGranted += 2
 		}
 		if extraArmiesGranted == 2 {
@@ -288,65 +449,56 @@ func (gs GameState) TradeInSet(hand []RiskCard, setIndices []int) []RiskCard {
 	return hand
 }
 
-// ArmiesForThisExchange calculates how many armies to award given the nth exchange. //TODO REFACTOR IN METADATA / RULES
-// The first set traded in - 4 armies
-// The second set - 6 armies
-// The third set - 8 armies
-// The fourth set - 10 armies
-// The fifth set - 12 armies
-// The sixth set - 15 armies
-// After the sixth set, each additional set is worth 5 more than the previous.
-func (gs *GameState) ArmiesForThisExchange(exchangeNumber int) int {
-
-	switch exchangeNumber {
-	case 1:
-		return 4
-	case 2:
-		return 6
-	case 3:
-		return 8
-	case 4:
-		return 10
-	case 5:
-		return 12
-	case 6:
-		return 15
-	default:
-		// After sixth, it's 15 + 5*(exchangeNumber-6)
-		return 15 + 5*(exchangeNumber-6)
-	}
-}
-
 // LegalMoves returns all legal moves for the current player.
 func (gs GameState) LegalMoves() []Move {
-	switch gs.Phase {
-	case ReinforcementPhase:
-		return gs.reinforcementMoves()
-	case AttackPhase:
-		return gs.attackMoves()
-	case ManeuverPhase:
-		return gs.maneuverMoves()
-	default:
-		return nil
+	return gs.generateMoves(gs.buffer())
+}
+
+// generateMoves generates the legal moves of the current player into the
+// given buffers
+func (gs *GameState) generateMoves(buf *buffers) []Move {
+	buf.moves = buf.moves[:0]
+	if gs.Won == "" { // Game not over
+		switch gs.Phase {
+		case ReinforcementPhase:
+			gs.reinforcementMoves(buf)
+		case AttackPhase:
+			gs.attackMoves(buf)
+		case ManeuverPhase:
+			gs.maneuverMoves(buf)
+		case ClaimPhase:
+			gs.claimMoves(buf)
+		case PlacementPhase:
+			gs.placementMoves(buf)
+		case OccupyPhase:
+			gs.occupyMoves(buf)
+		case DefendPhase:
+			gs.defendMoves(buf)
+		}
 	}
+	return buf.legalMoves()
 }
 
 // reinforcementMoves generates all possible reinforcement moves for the current player.
-func (gs GameState) reinforcementMoves() []Move {
-	var moves []Move
-	remainingTroops := gs.TroopsToPlace
-	enemyAdjacentTerritories := gs.getEnemyAdjacentTerritories()
-
-	// fmt.Printf("[reinforcementMoves] Player %d, TroopsToPlace=%d\n", gs.CurrentPlayer, gs.TroopsToPlace)
-	// fmt.Printf("[reinforcementMoves] EnemyAdjacentTerritories=%v\n", enemyAdjacentTerritories)
+// A player holding 5 or more cards must trade in a set before placing troops.
+func (gs *GameState) reinforcementMoves(buf *buffers) {
+	gs.tradeMoves(buf)
+	if len(gs.PlayerHands[gs.CurrentPlayer]) >= MandatoryTradeHandSize && len(buf.moves) > 0 {
+		return
+	}
 
+	remainingTroops := gs.TroopsToPlace
 	// Possible troop amounts: one, half, all
-	troopAmounts := []int{1, remainingTroops / 2, remainingTroops}
+	troopAmounts := [...]int{1, remainingTroops / 2, remainingTroops}
 
-	for _, cantonID := range enemyAdjacentTerritories {
+	// Reinforce the territories bordering an enemy, in ascending order
+	for cantonID, owner := range gs.Ownership {
+		if owner != gs.CurrentPlayer || !gs.bordersEnemy(cantonID) {
+			continue
+		}
 		for _, amount := range troopAmounts {
 			if amount > 0 && amount <= remainingTroops {
-				moves = append(moves, &GameMove{
+				buf.moves = append(buf.moves, GameMove{
 					ActionType: ReinforceAction,
 					ToCantonID: cantonID,
 					NumTroops:  amount,
@@ -354,100 +506,152 @@ func (gs GameState) reinforcementMoves() []Move {
 			}
 		}
 	}
-	// fmt.Printf("[reinforcementMoves] Generated %d moves\n", len(moves))
-	return moves
 }
 
-func (gs GameState) attackMoves() []Move {
-	var moves []Move
-	// fmt.Printf("[attackMoves] Player %d attacking...\n", gs.CurrentPlayer)
+// tradeMoves generates a move for each distinct set of cards the current player can trade in.
+func (gs *GameState) tradeMoves(buf *buffers) {
+	hand := gs.PlayerHands[gs.CurrentPlayer]
+	buf.sets = buf.sets[:0]
+
+	for i := 0; i < len(hand); i++ {
+		for j := i + 1; j < len(hand); j++ {
+			for k := j + 1; k < len(hand); k++ {
+				set := [SetSize]RiskCard{hand[i], hand[j], hand[k]}
+				if !IsSet(set) {
+					continue
+				}
+				// Skip sets of identical cards (e.g. swapping one wild for another)
+				sortSet(&set)
+				if slices.Contains(buf.sets, set) {
+					continue
+				}
+				buf.sets = append(buf.sets, set)
+
+				buf.moves = append(buf.moves, GameMove{
+					ActionType:  TradeCardsAction,
+					CardIndices: [SetSize]int{i, j, k},
+				})
+			}
+		}
+	}
+}
+
+// sortSet orders the cards of a set by type then territory
+func sortSet(set *[SetSize]RiskCard) {
+	for i := 1; i < len(set); i++ {
+		for j := i; j > 0 && (set[j].Type < set[j-1].Type ||
+			set[j].Type == set[j-1].Type && set[j].TerritoryID < set[j-1].TerritoryID); j-- {
+			set[j], set[j-1] = set[j-1], set[j]
+		}
+	}
+}
 
+// attackMoves generates an attack committing every troop but one on each enemy
+// canton adjacent to the current player's cantons. Under stop-loss attacks, the
+// attacker may also commit only half of its troops.
+func (gs *GameState) attackMoves(buf *buffers) {
+	stopLoss := gs.Rules.AttackGranularity() == StopLossAttack
 	for cantonID, owner := range gs.Ownership {
 		if owner == gs.CurrentPlayer && gs.TroopCounts[cantonID] > 1 {
 			numTroops := gs.TroopCounts[cantonID] - 1
 			for _, adjID := range gs.Map.Cantons[cantonID].AdjacentIDs {
-				if gs.Ownership[adjID] != gs.CurrentPlayer {
-					moves = append(moves, &GameMove{
+				if gs.Ownership[adjID] == gs.CurrentPlayer {
+					continue
+				}
+				if half := numTroops / 2; stopLoss && half > 0 {
+					buf.moves = append(buf.moves, GameMove{
 						ActionType:   AttackAction,
 						FromCantonID: cantonID,
 						ToCantonID:   adjID,
-						NumTroops:    numTroops,
+						NumTroops:    half,
 					})
 				}
+				buf.moves = append(buf.moves, GameMove{
+					ActionType:   AttackAction,
+					FromCantonID: cantonID,
+					ToCantonID:   adjID,
+					NumTroops:    numTroops,
+				})
 			}
 		}
 	}
 
 	// Pass always allowed
-	moves = append(moves, &GameMove{
+	buf.moves = append(buf.moves, GameMove{
 		ActionType: PassAction,
 	})
-	// fmt.Printf("[attackMoves] Generated %d moves\n", len(moves))
-	return moves
 }
 
-func (gs GameState) getPlayerTerritoriesWithTroops() []int {
-	var territories []int
-	for cantonID, owner := range gs.Ownership {
-		if owner == gs.CurrentPlayer && gs.TroopCounts[cantonID] > 1 {
-			territories = append(territories, cantonID)
-		}
-	}
-	return territories
-}
+// maneuverMoves generates all possible maneuver moves for the current player
+// under the fortify rule.
+func (gs *GameState) maneuverMoves(buf *buffers) {
+	components := gs.labelComponents(gs.CurrentPlayer, buf)
+	adjacentOnly := gs.Rules.FortifyRule() == AdjacentFortify
 
-func (gs GameState) getPlayerOwnedTerritories() []int {
-	var territories []int
-	for cantonID, owner := range gs.Ownership {
-		if owner == gs.CurrentPlayer {
-			territories = append(territories, cantonID)
+	for fromID, owner := range gs.Ownership {
+		maxTroops := gs.TroopCounts[fromID] - 1
+		if owner != gs.CurrentPlayer || maxTroops <= 0 || gs.hasFortified(fromID) {
+			continue
 		}
-	}
-	return territories
-}
-
-// maneuverMoves generates all possible maneuver moves for the current player.
-func (gs GameState) maneuverMoves() []Move {
-	var moves []Move
-	// fmt.Printf("[maneuverMoves] Player %d maneuvering...\n", gs.CurrentPlayer)
-
-	fromTerritories := gs.getPlayerTerritoriesWithTroops()
-	toTerritories := gs.getPlayerOwnedTerritories()
-
-	for _, fromID := range fromTerritories {
-		for _, toID := range toTerritories {
-			if fromID != toID && gs.AreConnected(fromID, toID, gs.CurrentPlayer) {
-				maxTroops := gs.TroopCounts[fromID] - 1
-				if maxTroops <= 0 {
-					continue
-				}
-				halfTroops := maxTroops / 2
-				troopAmounts := []int{1, halfTroops, maxTroops}
-
-				for _, numTroops := range troopAmounts {
-					if numTroops > 0 {
-						moves = append(moves, &GameMove{
-							ActionType:   ManeuverAction,
-							FromCantonID: fromID,
-							ToCantonID:   toID,
-							NumTroops:    numTroops,
-						})
-					}
+		troopAmounts := [...]int{1, maxTroops / 2, maxTroops}
+		for toID := range gs.Ownership {
+			if fromID == toID || components[toID] != components[fromID] {
+				continue
+			}
+			if adjacentOnly && !slices.Contains(gs.Map.Cantons[fromID].AdjacentIDs, toID) {
+				continue
+			}
+			for _, numTroops := range troopAmounts {
+				if numTroops > 0 {
+					buf.moves = append(buf.moves, GameMove{
+						ActionType:   ManeuverAction,
+						FromCantonID: fromID,
+						ToCantonID:   toID,
+						NumTroops:    numTroops,
+					})
 				}
 			}
 		}
 	}
 
 	// Pass always allowed
-	moves = append(moves, &GameMove{
+	buf.moves = append(buf.moves, GameMove{
 		ActionType: PassAction,
 	})
-	// fmt.Printf("[maneuverMoves] Generated %d moves\n", len(moves))
-	return moves
+}
+
+// labelComponents labels each canton owned by the player with the lowest ID of
+// the cantons it is connected to through the player's cantons, and every other
+// canton with -1
+func (gs *GameState) labelComponents(playerID int, buf *buffers) []int {
+	labels := resize(buf.marks, len(gs.Ownership))
+	for cantonID := range labels {
+		labels[cantonID] = -1
+	}
+	for cantonID, owner := range gs.Ownership {
+		if owner != playerID || labels[cantonID] != -1 {
+			continue
+		}
+		labels[cantonID] = cantonID
+		queue := append(buf.queue[:0], cantonID)
+		for len(queue) > 0 {
+			current := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			for _, adjID := range gs.Map.Cantons[current].AdjacentIDs {
+				if gs.Ownership[adjID] == playerID && labels[adjID] == -1 {
+					labels[adjID] = cantonID
+					queue = append(queue, adjID)
+				}
+			}
+		}
+		buf.queue = queue
+	}
+	buf.marks = labels
+	return labels
 }
 
 // MoveTroops transfers troops between two cantons owned by the same player.
-func (gs GameState) MoveTroops(fromCantonID, toCantonID, numTroops int) error {
+func (gs *GameState) MoveTroops(fromCantonID, toCantonID, numTroops int) error {
 	playerID := gs.Ownership[fromCantonID]
 
 	// Check ownership
@@ -463,79 +667,135 @@ func (gs GameState) MoveTroops(fromCantonID, toCantonID, numTroops int) error {
 		return fmt.Errorf("cannot move troops: not enough troops in the source canton")
 	}
 	// Move troops
-	gs.TroopCounts[fromCantonID] -= numTroops
-	gs.TroopCounts[toCantonID] += numTroops
+	gs.setTroops(fromCantonID, gs.TroopCounts[fromCantonID]-numTroops)
+	gs.setTroops(toCantonID, gs.TroopCounts[toCantonID]+numTroops)
 	return nil
 }
 
-func (gs GameState) Attack(attackerID, defenderID int) (GameState, error) {
+// Attack resolves an attack between two cantons committing the given number of
+// troops, returning the state after as much of the battle as the attack
+// granularity of the rules resolves. The defender rolls as many dice as it can.
+func (gs GameState) Attack(attackerID, defenderID, numTroops int) (GameState, error) {
 	newGs := gs.Copy()
+	err := newGs.attack(attackerID, defenderID, numTroops, gs.Rules.MaxDefendTroops())
+	return newGs, err
+}
+
+// checkAttack checks that an attack committing the given number of troops can
+// be launched
+func (gs *GameState) checkAttack(attackerID, defenderID, numTroops int) error {
 	// Check ownership and adjacency
-	if newGs.Ownership[attackerID] == gs.Ownership[defenderID] {
-		return newGs, fmt.Errorf("cannot attack: target canton is owned by the same player")
+	if gs.Ownership[attackerID] == gs.Ownership[defenderID] {
+		return fmt.Errorf("cannot attack: target canton is owned by the same player")
+	}
+	if !gs.AreAdjacent(attackerID, defenderID) {
+		return fmt.Errorf("cannot attack: cantons are not adjacent")
 	}
-	if !newGs.AreAdjacent(attackerID, defenderID) {
-		return newGs, fmt.Errorf("cannot attack: cantons are not adjacent")
+	if gs.TroopCounts[attackerID] <= 1 {
+		return fmt.Errorf("cannot attack: not enough troops to attack")
 	}
-	if newGs.TroopCounts[attackerID] <= 1 {
-		return newGs, fmt.Errorf("cannot attack: not enough troops to attack")
+	if gs.Rules.AttackGranularity() == StopLossAttack && (numTroops <= 0 || numTroops >= gs.TroopCounts[attackerID]) {
+		return fmt.Errorf("cannot attack: committing %d of %d troops", numTroops, gs.TroopCounts[attackerID]-1)
+	}
+	return nil
+}
+
+// attack resolves an attack in place, the defender rolling up to the given
+// number of dice
+func (gs *GameState) attack(attackerID, defenderID, numTroops, maxDefendDice int) error {
+	if err := gs.checkAttack(attackerID, defenderID, numTroops); err != nil {
+		return err
 	}
 
 	// Initialize troop counts
 	attackerTroops := gs.TroopCounts[attackerID] - 1 // Must leave at least one troop behind
 	defenderTroops := gs.TroopCounts[defenderID]
+	granularity := gs.Rules.AttackGranularity()
+	stopAt := 0 // Attacking troops left when the attack stops
+	if granularity == StopLossAttack {
+		stopAt = attackerTroops - numTroops
+	}
 
 	// Simulate attack rounds
-	for attackerTroops > 0 && defenderTroops > 0 {
-		// Determine dice count
-		attackerDice := min(attackerTroops, newGs.Rules.MaxAttackTroops())
-		defenderDice := min(defenderTroops, newGs.Rules.MaxDefendTroops())
-
-		attackerRolls := rollDice(attackerDice)
-		defenderRolls := rollDice(defenderDice)
+	buf := gs.buffer()
+	attackerDice := 0
+	for attackerTroops > stopAt && defenderTroops > 0 {
+		// Determine dice count, rolling only with the troops committed
+		attackerDice = min(attackerTroops-stopAt, gs.Rules.MaxAttackTroops())
+		defenderDice := min(defenderTroops, maxDefendDice)
 
-		// Sort dice rolls
-		sort.Sort(sort.Reverse(sort.IntSlice(attackerRolls)))
-		sort.Sort(sort.Reverse(sort.IntSlice(defenderRolls)))
+		// Roll dice, sorted from highest to lowest
+		buf.attackDice = rollDice(gs.random(), buf.attackDice, attackerDice)
+		buf.defendDice = rollDice(gs.random(), buf.defendDice, defenderDice)
 
 		// Determine outcome
-		attackerLosses, defenderLosses := newGs.Rules.DetermineAttackOutcome(attackerRolls, defenderRolls)
+		attackerLosses, defenderLosses := gs.Rules.DetermineAttackOutcome(buf.attackDice, buf.defendDice)
 
 		// Apply losses
 		attackerTroops -= attackerLosses
 		defenderTroops -= defenderLosses
 
-		// Break if either side is defeated
-		if attackerTroops <= 0 || defenderTroops <= 0 {
+		// Break if either side is defeated, or after a single roll
+		if attackerTroops <= 0 || defenderTroops <= 0 || granularity == SingleRollAttack {
 			break
 		}
 	}
 
 	// Update troop counts and ownership
-	newGs.TroopCounts[attackerID] = attackerTroops + 1 // Add back the troop left behind
+	gs.setTroops(attackerID, attackerTroops+1) // Add back the troop left behind
 
 	if defenderTroops <= 0 {
 		// Capture the canton
-		newGs.Ownership[defenderID] = newGs.Ownership[attackerID]
-		moveTroops := newGs.TroopCounts[attackerID] - 1 // Move all but one troop
-		newGs.TroopCounts[attackerID] -= moveTroops
-		newGs.TroopCounts[defenderID] = moveTroops
-		newGs.ConqueredThisTurn = true
+		defender := gs.Ownership[defenderID]
+		gs.setOwner(defenderID, gs.Ownership[attackerID])
+		moveTroops := gs.TroopCounts[attackerID] - 1 // Move all but one troop
+		if gs.Rules.ChooseOccupation() {
+			moveTroops = min(moveTroops, attackerDice) // Move in at least the dice rolled, and choose how many more next
+		}
+		gs.setTroops(attackerID, gs.TroopCounts[attackerID]-moveTroops)
+		gs.setTroops(defenderID, moveTroops)
+		gs.setConquered(true)
+
+		if defender > 0 && !gs.hasCantons(defender) {
+			gs.eliminate(defender)
+		}
+		if gs.TroopCounts[attackerID] > 1 && gs.Rules.ChooseOccupation() {
+			gs.setOccupation(Occupation{From: attackerID, To: defenderID})
+			gs.setPhase(OccupyPhase)
+		}
 	} else {
 		// Defender survives
-		newGs.TroopCounts[defenderID] = defenderTroops
-		newGs.ConqueredThisTurn = false
+		gs.setTroops(defenderID, defenderTroops)
 	}
 
-	return newGs, nil
+	return nil
 }
 
-func rollDice(num int) []int {
-	rolls := make([]int, num)
+// eliminate knocks a player out of the game and hands its cards over to the
+// current player, who must trade in sets right away if holding too many cards
+func (gs *GameState) eliminate(playerID int) {
+	gs.addEliminated(playerID)
+
+	conqueror := gs.CurrentPlayer
+	for _, card := range gs.PlayerHands[playerID] {
+		gs.addToHand(conqueror, card)
+	}
+	gs.setHand(playerID, []RiskCard{})
+
+	// Trade in till fewer cards than a mandatory trade remain, then place the
+	// received troops before resuming the attack
+	if len(gs.PlayerHands[conqueror]) >= EliminationTradeHandSize {
+		gs.setPhase(ReinforcementPhase)
+	}
+}
+
+// rollDice rolls a number of dice into rolls, sorted from highest to lowest
+func rollDice(rng Rand, rolls []int, num int) []int {
+	rolls = rolls[:0]
 	for i := 0; i < num; i++ {
-		rolls[i] = rand.Intn(6) + 1
+		rolls = append(rolls, rng.Intn(6)+1)
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(rolls)))
+	slices.SortFunc(rolls, func(a, b int) int { return b - a })
 	return rolls
 }
 
@@ -546,50 +806,45 @@ func min(a, b int) int {
 	return b
 }
 
-// Player returns the identifier of the current player.
+// Player returns the identifier of the player to move, see Decider
 func (gs GameState) Player() string {
-	return fmt.Sprintf("Player%d", gs.CurrentPlayer)
+	return playerName(gs.Decider())
 }
 
-// AreAdjacent checks if two cantons are adjacent on the map.
-func (gs GameState) AreAdjacent(cantonID1, cantonID2 int) bool {
-	for _, adjID := range gs.Map.Cantons[cantonID1].AdjacentIDs {
-		if adjID == cantonID2 {
-			return true
-		}
+// Decider returns the player who decides the next move: the defender while it
+// chooses its dice, and the current player otherwise
+func (gs GameState) Decider() int {
+	if gs.Phase == DefendPhase {
+		return gs.Ownership[gs.Battle.To]
 	}
-	return false
+	return gs.CurrentPlayer
 }
 
-func (gs GameState) Hash() StateHash {
-	hasher := fnv.New64a()
-
-	// Hash current player
-	binary.Write(hasher, binary.LittleEndian, int64(gs.CurrentPlayer))
-
-	// Hash phase
-	binary.Write(hasher, binary.LittleEndian, int64(gs.Phase))
-
-	// Hash troop counts
-	for _, count := range gs.TroopCounts {
-		binary.Write(hasher, binary.LittleEndian, int64(count))
+// playerNames caches the identifiers of players, which are asked for on every
+// step of a search
+var playerNames = func() []string {
+	names := make([]string, MaxPlayers+1)
+	for playerID := range names {
+		names[playerID] = fmt.Sprintf("Player%d", playerID)
 	}
+	return names
+}()
 
-	// Hash ownerships
-	for _, owner := range gs.Ownership {
-		binary.Write(hasher, binary.LittleEndian, int64(owner))
+func playerName(playerID int) string {
+	if playerID >= 0 && playerID < len(playerNames) {
+		return playerNames[playerID]
 	}
+	return fmt.Sprintf("Player%d", playerID)
+}
 
-	// Hash TroopsToPlace
-	binary.Write(hasher, binary.LittleEndian, int64(gs.TroopsToPlace))
-
-	// Hash PlayerTroops
-	for playerID, troops := range gs.PlayerTroops {
-		binary.Write(hasher, binary.LittleEndian, int64(playerID))
-		binary.Write(hasher, binary.LittleEndian, int64(troops))
+// AreAdjacent checks if two cantons are adjacent on the map.
+func (gs GameState) AreAdjacent(cantonID1, cantonID2 int) bool {
+	for _, adjID := range gs.Map.Cantons[cantonID1].AdjacentIDs {
+		if adjID == cantonID2 {
+			return true
+		}
 	}
-
-	return StateHash(hasher.Sum64())
+	return false
 }
 
 // Just BFS
@@ -597,147 +852,190 @@ func (gs GameState) AreConnected(fromID, toID, playerID int) bool {
 	if fromID == toID {
 		return true
 	}
-	visited := make(map[int]bool)
-	queue := []int{fromID}
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-
-		if visited[current] {
-			continue
-		}
-		visited[current] = true
+	buf := gs.buffer()
+	visited := resize(buf.marks, len(gs.Ownership))
+	for i := range visited {
+		visited[i] = 0
+	}
+	visited[fromID] = 1
+	queue := append(buf.queue[:0], fromID)
 
-		for _, adjID := range gs.Map.Cantons[current].AdjacentIDs {
-			if gs.Ownership[adjID] != playerID {
+	connected := false
+	for head := 0; head < len(queue) && !connected; head++ {
+		for _, adjID := range gs.Map.Cantons[queue[head]].AdjacentIDs {
+			if gs.Ownership[adjID] != playerID || visited[adjID] != 0 {
 				continue
 			}
 			if adjID == toID {
-				return true
-			}
-			if !visited[adjID] {
-				queue = append(queue, adjID)
+				connected = true
+				break
 			}
+			visited[adjID] = 1
+			queue = append(queue, adjID)
 		}
 	}
-	return false
+	buf.marks, buf.queue = visited, queue
+	return connected
 }
 
 func (gs GameState) Play(move Move) State {
 	newGs := gs.Copy()
-	gameMove := move.(*GameMove)
+	if !newGs.hashed {
+		newGs.Rehash()
+	}
+	newGs.apply(move.(*GameMove))
 
+	if DebugHash {
+		newGs.checkHash()
+	}
+	return &newGs
+}
+
+// apply plays a move in place, panicking if the move is invalid
+func (gs *GameState) apply(gameMove *GameMove) {
 	// fmt.Printf("[Play] Called with Phase=%d, ActionType=%d, TroopsToPlace=%d\n",
 	// gs.Phase, gameMove.ActionType, gs.TroopsToPlace)
 	switch gs.Phase {
 	case ReinforcementPhase:
-		if gameMove.ActionType == ReinforceAction {
+		if gameMove.ActionType == TradeCardsAction {
+			hand := gs.PlayerHands[gs.CurrentPlayer]
+			if !gs.canTrade(hand, gameMove.CardIndices) {
+				panic(fmt.Sprintf("Invalid set %+v for hand %+v", gameMove.CardIndices, hand))
+			}
+			indices := gameMove.CardIndices // Copy since trading in sorts the indices
+			gs.setHand(gs.CurrentPlayer, gs.TradeInSet(gs.ownHand(gs.CurrentPlayer), indices[:]))
+		} else if gameMove.ActionType == ReinforceAction {
 			// Apply reinforcement move
-			newGs.TroopCounts[gameMove.ToCantonID] += gameMove.NumTroops
+			gs.setTroops(gameMove.ToCantonID, gs.TroopCounts[gameMove.ToCantonID]+gameMove.NumTroops)
 			// Subtract placed troops from troops to place
-			newGs.TroopsToPlace -= gameMove.NumTroops
+			gs.setTroopsToPlace(gs.TroopsToPlace - gameMove.NumTroops)
 
 			// Panic if TroopsToPlace becomes negative
-			if newGs.TroopsToPlace < 0 {
+			if gs.TroopsToPlace < 0 {
 				panic("TroopsToPlace cannot be negative")
 			}
 
-			if newGs.TroopsToPlace == 0 {
-				newGs = newGs.AdvancePhase()
+			if gs.TroopsToPlace == 0 {
+				gs.advancePhase()
 			}
 		} else {
 			// Invalid action for this phase
 			panic(fmt.Sprintf("Invalid action %+v for ReinforcementPhase", gameMove))
 		}
 	case AttackPhase:
-		if gameMove.ActionType == AttackAction {
+		if gameMove.ActionType == AttackAction && gs.Rules.ChooseDefenderDice() {
+			// Leave the dice to the defender
+			if err := gs.declareAttack(gameMove.FromCantonID, gameMove.ToCantonID, gameMove.NumTroops); err != nil {
+				panic(err)
+			}
+		} else if gameMove.ActionType == AttackAction {
 			// Apply attack move
-			newState, err := newGs.Attack(gameMove.FromCantonID, gameMove.ToCantonID)
-			newGs = newState
-			if err != nil {
+			if err := gs.attack(gameMove.FromCantonID, gameMove.ToCantonID, gameMove.NumTroops, gs.Rules.MaxDefendTroops()); err != nil {
 				// Panic on error
 				panic(err)
 			}
 		} else if gameMove.ActionType == PassAction {
 			// End attack phase
-			newGs = newGs.AdvancePhase()
+			gs.advancePhase()
 		} else {
 			// Invalid action for this phase
 			panic(fmt.Sprintf("Invalid action %+v for AttackPhase", gameMove))
 		}
 	case ManeuverPhase:
 		if gameMove.ActionType == ManeuverAction {
-			// Apply maneuver move
-			err := newGs.MoveTroops(gameMove.FromCantonID, gameMove.ToCantonID, gameMove.NumTroops)
-			if err != nil {
+			// Apply maneuver move, ending the phase once the maneuvers allowed are made
+			if err := gs.maneuver(gameMove.FromCantonID, gameMove.ToCantonID, gameMove.NumTroops); err != nil {
 				// Panic on error
 				panic(err)
 			}
-			// After one maneuver, end the phase
-			newGs = newGs.AdvancePhase()
 		} else if gameMove.ActionType == PassAction {
 			// End maneuver phase
-			newGs = newGs.AdvancePhase()
+			gs.advancePhase()
 		} else {
 			// Invalid action for this phase
 			panic(fmt.Sprintf("Invalid action %+v for ManeuverPhase", gameMove))
 		}
+	case ClaimPhase:
+		if gameMove.ActionType == ClaimAction {
+			gs.claim(gameMove.ToCantonID)
+		} else {
+			panic(fmt.Sprintf("Invalid action %+v for ClaimPhase", gameMove))
+		}
+	case PlacementPhase:
+		if gameMove.ActionType == ReinforceAction {
+			gs.place(gameMove.ToCantonID, gameMove.NumTroops)
+		} else {
+			panic(fmt.Sprintf("Invalid action %+v for PlacementPhase", gameMove))
+		}
+	case DefendPhase:
+		if gameMove.ActionType == DefendAction {
+			if err := gs.defend(gameMove.NumTroops); err != nil {
+				panic(err)
+			}
+		} else {
+			panic(fmt.Sprintf("Invalid action %+v for DefendPhase", gameMove))
+		}
+	case OccupyPhase:
+		if gameMove.ActionType == OccupyAction {
+			if err := gs.occupy(gameMove.FromCantonID, gameMove.ToCantonID, gameMove.NumTroops); err != nil {
+				panic(err)
+			}
+		} else {
+			panic(fmt.Sprintf("Invalid action %+v for OccupyPhase", gameMove))
+		}
 	default:
 		panic("Unknown game phase")
 	}
 
 	// Update the last move
-	newGs.LastMove = move
+	gs.setLastMove(gameMove)
 
-	// Check for winner
-	newGs.Won = newGs.CheckWinner()
-
-	return &newGs
+	// Check for winner, once every canton has been claimed
+	if gs.Phase != ClaimPhase {
+		gs.setWon(gs.CheckWinner())
+	}
 }
 
-// advancePhase moves the game to the next phase or next player's turn.
+// AdvancePhase moves the game to the next phase or next player's turn.
 func (gs GameState) AdvancePhase() GameState {
 	newGs := gs.Copy()
-	switch newGs.Phase {
+	newGs.advancePhase()
+	return newGs
+}
+
+func (gs *GameState) advancePhase() {
+	switch gs.Phase {
 	case ReinforcementPhase:
-		newGs.Phase = AttackPhase
+		gs.setPhase(AttackPhase)
 	case AttackPhase:
-		newGs.Phase = ManeuverPhase
+		gs.setPhase(ManeuverPhase)
 	case ManeuverPhase:
-		newGs.AwardCardIfEligible()
-		newGs.Phase = ReinforcementPhase
-		newGs.CurrentPlayer = gs.NextPlayer()
-		newGs.HandleCardTrading()
-		newGs = *newGs.calculateTroopsToPlace()
-	}
-	// fmt.Printf("[AdvancePhase] old=%v => new=%v (Player=%d)\n",
-	// 	gs.Phase, newGs.Phase, newGs.CurrentPlayer)
-	return newGs
+		gs.AwardCardIfEligible()
+		if gs.Maneuvers != 0 {
+			gs.setManeuvers(0)
+		}
+		if len(gs.Fortified) != 0 {
+			gs.clearFortified()
+		}
+		gs.setPhase(ReinforcementPhase)
+		gs.setCurrentPlayer(gs.NextPlayer())
+		gs.calculateTroopsToPlace()
+	}
 }
 
-func (gs GameState) getEnemyAdjacentTerritories() []int {
-	territoriesSet := make(map[int]struct{})
-	for cantonID, owner := range gs.Ownership {
-		if owner == gs.CurrentPlayer {
-			for _, adjID := range gs.Map.Cantons[cantonID].AdjacentIDs {
-				if gs.Ownership[adjID] != gs.CurrentPlayer {
-					territoriesSet[cantonID] = struct{}{}
-					break
-				}
-			}
+// bordersEnemy checks whether a canton is adjacent to a canton of another player
+func (gs *GameState) bordersEnemy(cantonID int) bool {
+	owner := gs.Ownership[cantonID]
+	for _, adjID := range gs.Map.Cantons[cantonID].AdjacentIDs {
+		if gs.Ownership[adjID] != owner {
+			return true
 		}
 	}
-	var territories []int
-	for t := range territoriesSet {
-		territories = append(territories, t)
-	}
-	return territories
+	return false
 }
 
 // calculateTroopsToPlace calculates the number of troops the current player should place.
-func (gs GameState) calculateTroopsToPlace() *GameState {
-	newGs := gs.Copy()
+func (gs *GameState) calculateTroopsToPlace() {
 	numTerritories := 0
 
 	for _, owner := range gs.Ownership {
@@ -746,7 +1044,7 @@ func (gs GameState) calculateTroopsToPlace() *GameState {
 		}
 	}
 
-	troops := max(3, numTerritories/3)
+	troops := gs.Rules.Reinforcements(numTerritories)
 
 	// Debug:
 	// fmt.Printf("[calculateTroopsToPlace] CurrentPlayer=%d, numTerritories=%d, baseTroops=%d\n",gs.CurrentPlayer, numTerritories, troops)
@@ -768,15 +1066,50 @@ func (gs GameState) calculateTroopsToPlace() *GameState {
 	// debug
 	// fmt.Printf("[calculateTroopsToPlace] Final troopsToPlace for Player %d = %d\n\n", gs.CurrentPlayer, troops)
 
-	newGs.TroopsToPlace = troops
-	return &newGs
+	gs.setTroopsToPlace(troops)
 }
 
+// NextPlayer returns the player who takes the turn after the current player,
+// skipping players who have been eliminated
 func (gs GameState) NextPlayer() int {
-	if gs.CurrentPlayer == 1 {
-		return 2
+	for i := 1; i < gs.NumPlayers; i++ {
+		playerID := (gs.CurrentPlayer+i-1)%gs.NumPlayers + 1
+		if !gs.IsEliminated(playerID) {
+			return playerID
+		}
+	}
+	return gs.CurrentPlayer
+}
+
+// IsEliminated checks whether a player has been knocked out of the game
+func (gs GameState) IsEliminated(playerID int) bool {
+	for _, eliminated := range gs.Eliminated {
+		if eliminated == playerID {
+			return true
+		}
 	}
-	return 1
+	return false
+}
+
+// hasCantons checks whether a player controls any canton
+func (gs GameState) hasCantons(playerID int) bool {
+	for _, owner := range gs.Ownership {
+		if owner == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// opponents returns the players other than the given player who are still in the game
+func (gs GameState) opponents(player int) []int {
+	var opponents []int
+	for playerID := 1; playerID <= gs.NumPlayers; playerID++ {
+		if playerID != player && !gs.IsEliminated(playerID) {
+			opponents = append(opponents, playerID)
+		}
+	}
+	return opponents
 }
 
 // gets the winner of the game
@@ -784,33 +1117,59 @@ func (gs GameState) Winner() string {
 	return gs.Won
 }
 
-func (gs GameState) CheckWinner() string {
-	// Count how many territories each player owns
-	playerTerritories := make(map[int]int)
+// Placements ranks player IDs from first to last place: players still in the
+// game by number of cantons controlled, followed by eliminated players in
+// reverse order of elimination
+func (gs GameState) Placements() []int {
+	cantons := make([]int, gs.NumPlayers+1)
 	for _, owner := range gs.Ownership {
 		if owner > 0 {
-			playerTerritories[owner]++
+			cantons[owner]++
 		}
 	}
 
-	// If only one player has territories, that player is the winner
-	if len(playerTerritories) == 1 {
-		for playerID := range playerTerritories {
-			return fmt.Sprintf("Player%d", playerID)
+	var placements []int
+	for playerID := 1; playerID <= gs.NumPlayers; playerID++ {
+		if !gs.IsEliminated(playerID) {
+			placements = append(placements, playerID)
 		}
 	}
+	sort.SliceStable(placements, func(i, j int) bool {
+		return cantons[placements[i]] > cantons[placements[j]]
+	})
 
-	// otherwise return empty string
-	return ""
+	for i := len(gs.Eliminated) - 1; i >= 0; i-- {
+		placements = append(placements, gs.Eliminated[i])
+	}
+	return placements
+}
+
+func (gs GameState) CheckWinner() string {
+	// If only one player has territories, that player is the winner
+	winner := 0
+	for _, owner := range gs.Ownership {
+		if owner <= 0 {
+			continue
+		}
+		if winner == 0 {
+			winner = owner
+		} else if owner != winner {
+			return ""
+		}
+	}
+	if winner == 0 {
+		return ""
+	}
+	return playerName(winner)
 }
 
 // assigning `troopsPerTerritory` troops to each territory.
-func (gs GameState) AssignTerritoriesEqually(numPlayers, troopsPerTerritory int) {
+func (gs *GameState) AssignTerritoriesEqually(numPlayers, troopsPerTerritory int) {
 	totalTerritories := len(gs.Map.Cantons)
 	for id := 0; id < totalTerritories; id++ {
 		playerID := (id % numPlayers) + 1 // 1,2,1,2,...
-		gs.Ownership[id] = playerID
-		gs.TroopCounts[id] = troopsPerTerritory
+		gs.setOwner(id, playerID)
+		gs.setTroops(id, troopsPerTerritory)
 	}
 }
 
@@ -822,7 +1181,7 @@ func IsMoveValidForPhase(phase Phase, move Move) bool {
 
 	switch phase {
 	case ReinforcementPhase:
-		return gm.ActionType == ReinforceAction || gm.ActionType == PassAction
+		return gm.ActionType == ReinforceAction || gm.ActionType == TradeCardsAction || gm.ActionType == PassAction
 
 	case AttackPhase:
 		return gm.ActionType == AttackAction || gm.ActionType == PassAction
@@ -830,6 +1189,18 @@ func IsMoveValidForPhase(phase Phase, move Move) bool {
 	case ManeuverPhase:
 		return gm.ActionType == ManeuverAction || gm.ActionType == PassAction
 
+	case ClaimPhase:
+		return gm.ActionType == ClaimAction
+
+	case PlacementPhase:
+		return gm.ActionType == ReinforceAction
+
+	case OccupyPhase:
+		return gm.ActionType == OccupyAction
+
+	case DefendPhase:
+		return gm.ActionType == DefendAction
+
 	default:
 		return false
 	}
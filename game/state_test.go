@@ -0,0 +1,527 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGameState(t *testing.T) {
+	t.Run("defaults to two players", func(t *testing.T) {
+		gs := NewGameState(CreateMap(), NewStandardRules())
+
+		require.Equal(t, 2, gs.NumPlayers, "Should default to two players")
+		require.Len(t, gs.PlayerHands, 3, "Should have a hand per player (index 0 unused)")
+	})
+
+	t.Run("deals cantons among all players", func(t *testing.T) {
+		for numPlayers := MinPlayers; numPlayers <= MaxPlayers; numPlayers++ {
+			gs := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(numPlayers))
+
+			require.Equal(t, numPlayers, gs.NumPlayers)
+			require.Len(t, gs.PlayerHands, numPlayers+1, "Should have a hand per player (index 0 unused)")
+			require.GreaterOrEqual(t, gs.CurrentPlayer, 1, "Starting player should be a valid player")
+			require.LessOrEqual(t, gs.CurrentPlayer, numPlayers, "Starting player should be a valid player")
+			for playerID := 1; playerID <= numPlayers; playerID++ {
+				require.False(t, gs.IsEliminated(playerID), "Player %d should own cantons", playerID)
+			}
+		}
+	})
+
+	t.Run("deals the same game with the same seed", func(t *testing.T) {
+		a := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithSeed(7))
+		b := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3), WithSeed(7))
+
+		require.Equal(t, a.Ownership, b.Ownership)
+		require.Equal(t, a.Cards, b.Cards)
+		require.Equal(t, a.CurrentPlayer, b.CurrentPlayer)
+		require.Equal(t, a.Hash(), b.Hash())
+	})
+
+	t.Run("panics with an unsupported number of players", func(t *testing.T) {
+		require.Panics(t, func() {
+			NewGameState(CreateMap(), NewStandardRules(), WithPlayers(MinPlayers-1))
+		}, "Should panic with too few players")
+		require.Panics(t, func() {
+			NewGameState(CreateMap(), NewStandardRules(), WithPlayers(MaxPlayers+1))
+		}, "Should panic with too many players")
+	})
+}
+
+func TestUseRand(t *testing.T) {
+	gs := newEliminationState()
+	gs.TroopCounts[22] = 3
+	gs.TroopCounts[7] = 3
+	attack := &GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}
+
+	a := gs.UseRand(rand.New(rand.NewSource(1))).Play(attack)
+	b := gs.UseRand(rand.New(rand.NewSource(1))).Play(attack)
+
+	require.Equal(t, a.Hash(), b.Hash(), "Same source should roll the same dice")
+	require.Equal(t, 3, gs.TroopCounts[22], "Original state should be unchanged")
+}
+
+func TestCopy(t *testing.T) {
+	t.Run("shares the components a move leaves unchanged", func(t *testing.T) {
+		var state State = playedState(t, CreateMap())
+		for state.(*GameState).Phase != AttackPhase {
+			moves := state.LegalMoves()
+			state = state.Play(moves[len(moves)-1])
+		}
+		gs := state.(*GameState)
+		require.NotEmpty(t, gs.DiscardedCards)
+		require.NotEmpty(t, gs.Cards)
+
+		next := gs.Play(&GameMove{ActionType: PassAction}).(*GameState)
+
+		require.Same(t, &gs.TroopCounts[0], &next.TroopCounts[0], "Should share troops")
+		require.Same(t, &gs.Ownership[0], &next.Ownership[0], "Should share owners")
+		require.Same(t, &gs.Cards[0], &next.Cards[0], "Should share the deck")
+		require.Same(t, &gs.DiscardedCards[0], &next.DiscardedCards[0], "Should share the discarded cards")
+		require.Same(t, &gs.PlayerHands[0], &next.PlayerHands[0], "Should share the hands")
+	})
+
+	t.Run("is not changed by the mutators of the state copied", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		gs := playedState(t, CreateMap())
+		gs.rng = rand.New(rand.NewSource(1))
+		gs.unshare(allComponents) // The state owns its components till copied
+		copied := gs.Copy()
+		played := gs.Play(&GameMove{ActionType: PassAction}).(*GameState)
+		before, err := json.Marshal([]*GameState{&copied, played})
+		require.NoError(t, err)
+
+		from, to := -1, -1
+		for _, canton := range gs.Map.Cantons {
+			for _, neighbor := range canton.AdjacentIDs {
+				if gs.Ownership[canton.ID] == gs.Ownership[neighbor] && gs.TroopCounts[canton.ID] > 1 {
+					from, to = canton.ID, neighbor
+				}
+			}
+		}
+		require.NotEqual(t, -1, from, "Should find troops to move")
+		require.NoError(t, gs.MoveTroops(from, to, 1))
+		_, ok := gs.DrawCard()
+		require.True(t, ok)
+		gs.ConqueredThisTurn = true
+		gs.AwardCardIfEligible()
+		hand := []RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 3}, {Type: Infantry, TerritoryID: 6}}
+		gs.TradeInSet(hand, []int{0, 1, 2})
+		gs.InitCards(DefaultWildCards)
+		gs.AssignTerritoriesEqually(gs.NumPlayers, 5)
+
+		after, err := json.Marshal([]*GameState{&copied, played})
+		require.NoError(t, err)
+		require.JSONEq(t, string(before), string(after))
+		require.NotPanics(t, func() { copied.Hash(); played.Hash() }, "Should keep the hashes of the copies")
+	})
+
+	t.Run("copies and plays a state from several goroutines", func(t *testing.T) {
+		gs := playedState(t, CreateMap())
+		gs.unshare(allComponents) // The state owns its components till copied
+		before, err := json.Marshal(gs)
+		require.NoError(t, err)
+
+		played := make([]State, 8)
+		var wg sync.WaitGroup
+		for i := range played {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rng := rand.New(rand.NewSource(int64(i)))
+				copied := gs.Copy()
+				state := copied.UseRand(rng)
+				for j := 0; j < 50 && state.Winner() == ""; j++ {
+					moves := state.LegalMoves()
+					state = state.Play(moves[rng.Intn(len(moves))])
+				}
+				played[i] = state
+			}()
+		}
+		wg.Wait()
+
+		after, err := json.Marshal(gs)
+		require.NoError(t, err)
+		require.JSONEq(t, string(before), string(after), "Should not change the state copied")
+		for _, state := range played {
+			require.Equal(t, state.(*GameState).computeHash(), state.Hash(), "Should not change each other's states")
+		}
+	})
+
+	t.Run("never changes the state played from", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rng := rand.New(rand.NewSource(1))
+		var state State = NewGameState(CreateMap(), NewStandardRules(), WithPlayers(4), WithRand(rng))
+		for i := 0; i < 3000 && state.Winner() == ""; i++ {
+			before, err := json.Marshal(state)
+			require.NoError(t, err)
+
+			// Siblings must not change each other's shared components either
+			moves := state.LegalMoves()
+			siblings := []State{state.Play(moves[rng.Intn(len(moves))]), state.Play(moves[rng.Intn(len(moves))])}
+			require.NotPanics(t, func() { siblings[0].Hash() }, "Should not change the first sibling")
+
+			after, err := json.Marshal(state)
+			require.NoError(t, err)
+			require.JSONEq(t, string(before), string(after))
+			state = siblings[1]
+		}
+	})
+}
+
+func TestNextPlayer(t *testing.T) {
+	gs := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(4))
+
+	t.Run("cycles through all players", func(t *testing.T) {
+		gs.CurrentPlayer = 2
+		require.Equal(t, 3, gs.NextPlayer())
+		gs.CurrentPlayer = 4
+		require.Equal(t, 1, gs.NextPlayer(), "Should wrap around to the first player")
+	})
+
+	t.Run("skips eliminated players", func(t *testing.T) {
+		gs.Eliminated = []int{3}
+		gs.CurrentPlayer = 2
+		require.Equal(t, 4, gs.NextPlayer(), "Should skip eliminated player 3")
+	})
+}
+
+// newEliminationState sets up a 3-player game where player 1 is about to
+// capture the last canton of player 2 with an overwhelming army
+func newEliminationState() *GameState {
+	gs := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3))
+	for cantonID := range gs.Ownership {
+		gs.Ownership[cantonID] = 3
+		gs.TroopCounts[cantonID] = 1
+	}
+	gs.Ownership[7] = 2  // GE
+	gs.Ownership[22] = 1 // VD
+	gs.TroopCounts[22] = 1000
+	gs.CurrentPlayer = 1
+	gs.Phase = AttackPhase
+	gs.TroopsToPlace = 0
+	return gs
+}
+
+func TestElimination(t *testing.T) {
+	t.Run("records the eliminated player and transfers its cards", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.PlayerHands[1] = []RiskCard{{Type: Infantry, TerritoryID: 0}}
+		gs.PlayerHands[2] = []RiskCard{{Type: Cavalry, TerritoryID: 1}, {Type: Artillery, TerritoryID: 2}}
+
+		got := gs.Play(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}).(*GameState)
+
+		require.Equal(t, 1, got.Ownership[7], "Attacker should capture the canton")
+		require.Equal(t, []int{2}, got.Eliminated, "Defender should be eliminated")
+		require.True(t, got.IsEliminated(2))
+		require.Len(t, got.PlayerHands[1], 3, "Conqueror should receive the defender's cards")
+		require.Empty(t, got.PlayerHands[2], "Eliminated player should hold no cards")
+		require.Equal(t, AttackPhase, got.Phase, "Conqueror should keep attacking without a forced trade")
+		require.Equal(t, "", got.Winner(), "Game should go on while player 3 remains")
+	})
+
+	t.Run("forces a trade in when the conqueror holds 6 or more cards", func(t *testing.T) {
+		gs := newEliminationState()
+		gs.PlayerHands[1] = []RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Cavalry, TerritoryID: 2}}
+		gs.PlayerHands[2] = []RiskCard{{Type: Infantry, TerritoryID: 3}, {Type: Cavalry, TerritoryID: 4}, {Type: Artillery, TerritoryID: 5}}
+
+		got := gs.Play(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase, "Conqueror should trade in and place the received troops")
+		for _, move := range got.LegalMoves() {
+			require.Equal(t, TradeCardsAction, move.(*GameMove).ActionType, "Conqueror should only be allowed to trade in")
+		}
+
+		got = got.Play(got.LegalMoves()[0]).(*GameState)
+
+		require.Less(t, len(got.PlayerHands[1]), MandatoryTradeHandSize, "Conqueror should trade in down to 4 or fewer cards")
+		require.Equal(t, 1, got.Exchanges, "Conqueror should trade in one set")
+		require.Equal(t, 4, got.TroopsToPlace, "Conqueror should receive troops for the first exchange")
+	})
+
+	t.Run("ranks players by placement", func(t *testing.T) {
+		gs := newEliminationState()
+
+		got := gs.Play(&GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7}).(*GameState)
+
+		require.Equal(t, []int{3, 1, 2}, got.Placements(), "Remaining players should rank by cantons ahead of eliminated players")
+	})
+}
+
+func TestAttackGranularity(t *testing.T) {
+	newAttackState := func(granularity AttackGranularity, attackers, defenders int) *GameState {
+		gs := newEliminationState()
+		gs.Rules = &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Attack: granularity}
+		gs.TroopCounts[22] = attackers
+		gs.TroopCounts[7] = defenders
+		return gs
+	}
+	attack := func(numTroops int) *GameMove {
+		return &GameMove{ActionType: AttackAction, FromCantonID: 22, ToCantonID: 7, NumTroops: numTroops}
+	}
+
+	t.Run("blitzes to the end by default", func(t *testing.T) {
+		gs := newAttackState(BlitzAttack, 11, 1000)
+
+		got := gs.Play(attack(1)).(*GameState)
+
+		require.Equal(t, 1, got.TroopCounts[22], "Should fight till every troop committed or not is lost")
+	})
+
+	t.Run("rolls the dice once", func(t *testing.T) {
+		gs := newAttackState(SingleRollAttack, 1000, 100)
+
+		got := gs.Play(attack(999)).(*GameState)
+
+		require.Equal(t, 2, 1100-got.TroopCounts[22]-got.TroopCounts[7], "Should lose as many troops as dice compared")
+		require.Equal(t, 2, got.Ownership[7])
+		require.Equal(t, AttackPhase, got.Phase)
+	})
+
+	t.Run("stops once the troops committed are lost", func(t *testing.T) {
+		gs := newAttackState(StopLossAttack, 11, 1000)
+
+		got := gs.Play(attack(4)).(*GameState)
+
+		require.Equal(t, 7, got.TroopCounts[22], "Should keep the troops not committed")
+		require.Equal(t, 2, got.Ownership[7])
+	})
+
+	t.Run("offers to commit half or all of the troops", func(t *testing.T) {
+		gs := newAttackState(StopLossAttack, 11, 1000)
+
+		var committed []int
+		for _, move := range gs.LegalMoves() {
+			if gm := move.(*GameMove); gm.ActionType == AttackAction && gm.FromCantonID == 22 && gm.ToCantonID == 7 {
+				committed = append(committed, gm.NumTroops)
+			}
+		}
+		require.Equal(t, []int{5, 10}, committed)
+	})
+
+	t.Run("validates the troops committed", func(t *testing.T) {
+		gs := newAttackState(StopLossAttack, 11, 1000)
+
+		require.NoError(t, gs.ValidateMove(attack(10)))
+		require.ErrorIs(t, gs.ValidateMove(attack(0)), ErrInsufficientTroops)
+		require.ErrorIs(t, gs.ValidateMove(attack(11)), ErrInsufficientTroops)
+		require.NoError(t, newAttackState(BlitzAttack, 11, 1000).ValidateMove(attack(0)), "Should ignore the troops of a blitz")
+	})
+
+	t.Run("plays random games", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		for _, granularity := range []AttackGranularity{BlitzAttack, SingleRollAttack, StopLossAttack} {
+			rules := &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Attack: granularity}
+			rng := rand.New(rand.NewSource(1))
+			var state State = NewGameState(CreateMap(), rules, WithPlayers(3), WithRand(rng))
+			for i := 0; i < 2000 && state.Winner() == ""; i++ {
+				moves := state.LegalMoves()
+				for _, move := range moves {
+					require.NoError(t, state.(*GameState).ValidateMove(move))
+					require.Equal(t, move.(*GameMove).ActionType == AttackAction, move.IsStochastic())
+				}
+				state = state.Play(moves[rng.Intn(len(moves))])
+			}
+		}
+	})
+}
+
+func TestManeuvers(t *testing.T) {
+	newManeuverState := func(maneuvers int) *GameState {
+		gs := newEliminationState()
+		gs.Rules = &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Maneuvers: maneuvers}
+		gs.Ownership[gs.Map.Cantons[22].AdjacentIDs[0]] = 1
+		gs.Phase = ManeuverPhase
+		return gs
+	}
+	maneuver := func(gs *GameState) *GameMove {
+		return &GameMove{ActionType: ManeuverAction, FromCantonID: 22, ToCantonID: gs.Map.Cantons[22].AdjacentIDs[0], NumTroops: 1}
+	}
+
+	t.Run("ends the turn after a single maneuver by default", func(t *testing.T) {
+		gs := newManeuverState(0)
+
+		got := gs.Play(maneuver(gs)).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase)
+		require.Equal(t, 2, got.CurrentPlayer)
+		require.Zero(t, got.Maneuvers)
+	})
+
+	t.Run("ends the turn once the maneuvers allowed are made", func(t *testing.T) {
+		gs := newManeuverState(2)
+
+		got := gs.Play(maneuver(gs)).(*GameState)
+
+		require.Equal(t, ManeuverPhase, got.Phase, "Should allow another maneuver")
+		require.Equal(t, 1, got.Maneuvers)
+
+		got = got.Play(maneuver(gs)).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase)
+		require.Zero(t, got.Maneuvers, "Should reset the maneuvers for the next turn")
+	})
+
+	t.Run("maneuvers till passing without a limit", func(t *testing.T) {
+		var state State = newManeuverState(-1)
+		for i := 0; i < 10; i++ {
+			state = state.Play(maneuver(state.(*GameState)))
+		}
+		require.Equal(t, ManeuverPhase, state.(*GameState).Phase)
+
+		got := state.Play(&GameMove{ActionType: PassAction}).(*GameState)
+
+		require.Equal(t, ReinforcementPhase, got.Phase)
+		require.Zero(t, got.Maneuvers)
+	})
+
+	t.Run("plays random games in place", func(t *testing.T) {
+		DebugHash = true
+		defer func() { DebugHash = false }()
+
+		rules := &StandardRules{MaxAttackDice: 3, MaxDefendDice: 2, Maneuvers: 3}
+		rng := rand.New(rand.NewSource(1))
+		var state State = NewGameState(CreateMap(), rules, WithPlayers(3), WithSeed(1))
+		r := NewGameState(CreateMap(), rules, WithPlayers(3), WithSeed(1)).Rollout()
+		start := r.State().Hash()
+		played := 0
+		for ; played < 2000 && state.Winner() == ""; played++ {
+			moves := state.LegalMoves()
+			index := rng.Intn(len(moves))
+			state = state.Play(moves[index])
+			r.Apply(r.LegalMoves()[index])
+			require.Equal(t, state.Hash(), r.State().Hash(), "Should maneuver the same way in place")
+		}
+
+		for i := 0; i < played; i++ {
+			r.Undo()
+		}
+		require.Equal(t, start, r.State().Hash(), "Should undo the maneuvers")
+	})
+}
+
+func TestTradeCards(t *testing.T) {
+	newTradeState := func(hand []RiskCard) *GameState {
+		gs := NewGameState(CreateMap(), NewStandardRules())
+		gs.PlayerHands[gs.CurrentPlayer] = hand
+		return gs
+	}
+	countTrades := func(moves []Move) (trades int) {
+		for _, move := range moves {
+			if move.(*GameMove).ActionType == TradeCardsAction {
+				trades++
+			}
+		}
+		return trades
+	}
+
+	t.Run("offers no trade without a set", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Cavalry, TerritoryID: 2}})
+
+		require.Zero(t, countTrades(gs.LegalMoves()))
+	})
+
+	t.Run("offers an optional trade alongside reinforcements", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Cavalry, TerritoryID: 1}, {Type: Artillery, TerritoryID: 2}})
+		moves := gs.LegalMoves()
+
+		require.Equal(t, 1, countTrades(moves), "Should offer the only set")
+		require.Greater(t, len(moves), 1, "Should still allow placing troops instead")
+	})
+
+	t.Run("forces a trade with 5 or more cards", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{
+			{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Infantry, TerritoryID: 2},
+			{Type: Cavalry, TerritoryID: 3}, {Type: Wild, TerritoryID: -1},
+		})
+		moves := gs.LegalMoves()
+
+		require.Equal(t, len(moves), countTrades(moves), "Should only allow trading in")
+	})
+
+	t.Run("skips duplicate sets of identical cards", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Wild, TerritoryID: -1}, {Type: Wild, TerritoryID: -1}})
+
+		require.Equal(t, 1, countTrades(gs.LegalMoves()), "Should offer one set for interchangeable wilds")
+	})
+
+	t.Run("trading in grants escalating troops", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Cavalry, TerritoryID: 1}, {Type: Artillery, TerritoryID: 2}})
+		gs.Exchanges = 2
+		troops := gs.TroopsToPlace
+		move := &GameMove{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 1, 2}}
+
+		got := gs.Play(move).(*GameState)
+
+		require.Empty(t, got.PlayerHands[got.CurrentPlayer], "Cards should leave the hand")
+		require.Len(t, got.DiscardedCards, 3, "Cards should be discarded")
+		require.Equal(t, 3, got.Exchanges)
+		require.Equal(t, troops+8, got.TroopsToPlace, "Third exchange should grant 8 troops")
+		require.Equal(t, [SetSize]int{0, 1, 2}, move.CardIndices, "Move should not be modified")
+	})
+
+	t.Run("panics on an invalid set", func(t *testing.T) {
+		gs := newTradeState([]RiskCard{{Type: Infantry, TerritoryID: 0}, {Type: Infantry, TerritoryID: 1}, {Type: Cavalry, TerritoryID: 2}})
+
+		require.Panics(t, func() {
+			gs.Play(&GameMove{ActionType: TradeCardsAction, CardIndices: [SetSize]int{0, 1, 2}})
+		})
+	})
+}
+
+func TestEvaluateMultiplayer(t *testing.T) {
+	gs := NewGameState(CreateMap(), NewStandardRules(), WithPlayers(3))
+	// Give every player an identical position
+	for cantonID := range gs.Ownership {
+		gs.Ownership[cantonID] = -1
+		gs.TroopCounts[cantonID] = 0
+	}
+	for i := 0; i < 6; i++ {
+		gs.Ownership[i] = i%3 + 1
+		gs.TroopCounts[i] = 3
+	}
+
+	t.Run("scores an even position as neutral", func(t *testing.T) {
+		require.InDelta(t, 0.0, EvaluateResources(gs), 0.0001)
+	})
+
+	t.Run("scores against all opponents", func(t *testing.T) {
+		gs.CurrentPlayer = 1
+		gs.TroopCounts[2] = 9 // Strengthen player 3 only
+		score := EvaluateResources(gs)
+
+		require.Less(t, score, 0.0, "Should be penalized by any stronger opponent")
+	})
+}
+
+func BenchmarkPlay(b *testing.B) {
+	// Replays a game on the classic map, as searches play the moves of a tree
+	gs := NewGameState(CreateClassicMap(), NewStandardRules(), WithPlayers(3), WithSeed(1))
+	state := gs.UseRand(rand.New(rand.NewSource(1)))
+	var moves []Move
+	for len(moves) < 2000 && state.Winner() == "" {
+		legal := state.LegalMoves()
+		move := legal[len(moves)%len(legal)]
+		moves = append(moves, move)
+		state = state.Play(move)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		state = gs.UseRand(rand.New(rand.NewSource(1)))
+		for _, move := range moves {
+			state = state.Play(move)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(moves)), "ns/move")
+}
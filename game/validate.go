@@ -0,0 +1,283 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationKind classifies a map integrity problem
+type ValidationKind string
+
+const (
+	MismatchedID      ValidationKind = "mismatched ID"      // Canton or region stored under a different ID
+	NonDenseIDs       ValidationKind = "non-dense IDs"      // Canton IDs do not run from 0 to n-1
+	UnknownCanton     ValidationKind = "unknown canton"     // Reference to a canton that does not exist
+	UnknownRegion     ValidationKind = "unknown region"     // Reference to a region that does not exist
+	SelfLoop          ValidationKind = "self loop"          // Canton borders itself
+	AsymmetricBorder  ValidationKind = "asymmetric border"  // Border listed on one side only
+	Disconnected      ValidationKind = "disconnected"       // Canton unreachable from the rest of the map
+	UncoveredCanton   ValidationKind = "uncovered canton"   // Canton missing from its region
+	OverlappingRegion ValidationKind = "overlapping region" // Canton listed by more than one region
+	EmptyRegion       ValidationKind = "empty region"       // Region without cantons
+	NonPositiveBonus  ValidationKind = "non-positive bonus" // Region bonus is zero or negative
+)
+
+// ValidationError describes a single integrity problem found in a map
+type ValidationError struct {
+	Kind     ValidationKind
+	CantonID int // Canton at fault, -1 if not applicable
+	OtherID  int // Other canton or region involved, -1 if not applicable
+	RegionID int // Region at fault, -1 if not applicable
+}
+
+func (e ValidationError) Error() string {
+	var details []string
+	if e.CantonID >= 0 {
+		details = append(details, fmt.Sprintf("canton %d", e.CantonID))
+	}
+	if e.RegionID >= 0 {
+		details = append(details, fmt.Sprintf("region %d", e.RegionID))
+	}
+	if e.OtherID >= 0 {
+		details = append(details, fmt.Sprintf("other %d", e.OtherID))
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, strings.Join(details, ", "))
+}
+
+// ValidationErrors collects all integrity problems found in a map
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid map (%d problems): %s", len(errs), strings.Join(messages, "; "))
+}
+
+// Validate checks the integrity of the map: canton IDs, border symmetry,
+// self loops, unknown references, connectivity, region coverage, disjoint
+// regions and positive region bonuses. It returns ValidationErrors listing
+// every problem found, or nil if the map is valid.
+func (m *Map) Validate() error {
+	var errs ValidationErrors
+	report := func(kind ValidationKind, cantonID, regionID, otherID int) {
+		errs = append(errs, ValidationError{Kind: kind, CantonID: cantonID, RegionID: regionID, OtherID: otherID})
+	}
+
+	cantonIDs := sortedKeys(m.Cantons)
+	regionIDs := sortedKeys(m.Regions)
+
+	// Cantons are indexed by ID throughout the game state
+	for i, id := range cantonIDs {
+		if m.Cantons[id].ID != id {
+			report(MismatchedID, id, -1, m.Cantons[id].ID)
+		}
+		if id != i {
+			report(NonDenseIDs, id, -1, -1)
+		}
+	}
+
+	// Borders
+	for _, id := range cantonIDs {
+		seen := make(map[int]bool)
+		for _, adjID := range m.Cantons[id].AdjacentIDs {
+			if seen[adjID] {
+				continue
+			}
+			seen[adjID] = true
+			adj, ok := m.Cantons[adjID]
+			switch {
+			case !ok:
+				report(UnknownCanton, id, -1, adjID)
+			case adjID == id:
+				report(SelfLoop, id, -1, -1)
+			case !contains(adj.AdjacentIDs, id):
+				report(AsymmetricBorder, id, -1, adjID)
+			}
+		}
+	}
+
+	// Connectivity
+	if len(cantonIDs) > 0 {
+		reached := m.reachable(cantonIDs[0])
+		for _, id := range cantonIDs {
+			if !reached[id] {
+				report(Disconnected, id, -1, -1)
+			}
+		}
+	}
+
+	// Regions
+	regionsByCanton := make(map[int][]int)
+	for _, regionID := range regionIDs {
+		region := m.Regions[regionID]
+		if region.ID != regionID {
+			report(MismatchedID, -1, regionID, region.ID)
+		}
+		if len(region.CantonIDs) == 0 {
+			report(EmptyRegion, -1, regionID, -1)
+		}
+		if region.Bonus <= 0 {
+			report(NonPositiveBonus, -1, regionID, -1)
+		}
+		for _, cantonID := range region.CantonIDs {
+			if _, ok := m.Cantons[cantonID]; !ok {
+				report(UnknownCanton, -1, regionID, cantonID)
+				continue
+			}
+			regionsByCanton[cantonID] = append(regionsByCanton[cantonID], regionID)
+		}
+	}
+	for _, id := range cantonIDs {
+		regionID := m.Cantons[id].RegionID
+		if _, ok := m.Regions[regionID]; !ok {
+			report(UnknownRegion, id, -1, regionID)
+		} else if !contains(regionsByCanton[id], regionID) {
+			report(UncoveredCanton, id, regionID, -1)
+		}
+		if len(regionsByCanton[id]) > 1 {
+			for _, otherID := range regionsByCanton[id] {
+				if otherID != regionID {
+					report(OverlappingRegion, id, regionID, otherID)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// reachable finds all cantons reachable from a canton following its borders
+func (m *Map) reachable(fromID int) map[int]bool {
+	reached := map[int]bool{fromID: true}
+	queue := []int{fromID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, adjID := range m.Cantons[current].AdjacentIDs {
+			if _, ok := m.Cantons[adjID]; ok && !reached[adjID] {
+				reached[adjID] = true
+				queue = append(queue, adjID)
+			}
+		}
+	}
+	return reached
+}
+
+// DiffMaps compares two maps and describes every difference between them.
+// Cantons are matched by abbreviation and regions by name, so maps from
+// sources with different ID schemes can be compared. Region differences are
+// only reported if both maps define regions.
+func DiffMaps(a, b *Map) []string {
+	var diffs []string
+
+	cantonsA, cantonsB := cantonsByAbbreviation(a), cantonsByAbbreviation(b)
+	for _, abbrev := range sortedKeys(cantonsA) {
+		if _, ok := cantonsB[abbrev]; !ok {
+			diffs = append(diffs, fmt.Sprintf("- canton %s", abbrev))
+		}
+	}
+	for _, abbrev := range sortedKeys(cantonsB) {
+		if _, ok := cantonsA[abbrev]; !ok {
+			diffs = append(diffs, fmt.Sprintf("+ canton %s", abbrev))
+		}
+	}
+
+	bordersA, bordersB := borders(a), borders(b)
+	for _, border := range sortedKeys(bordersA) {
+		if !bordersB[border] {
+			diffs = append(diffs, fmt.Sprintf("- border %s", border))
+		}
+	}
+	for _, border := range sortedKeys(bordersB) {
+		if !bordersA[border] {
+			diffs = append(diffs, fmt.Sprintf("+ border %s", border))
+		}
+	}
+
+	if len(a.Regions) == 0 || len(b.Regions) == 0 {
+		return diffs
+	}
+
+	regionsA, regionsB := regionsByName(a), regionsByName(b)
+	for _, name := range sortedKeys(regionsA) {
+		regionB, ok := regionsB[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("- region %s", name))
+		} else if regionA := regionsA[name]; regionA.Bonus != regionB.Bonus {
+			diffs = append(diffs, fmt.Sprintf("~ region %s bonus %d -> %d", name, regionA.Bonus, regionB.Bonus))
+		}
+	}
+	for _, name := range sortedKeys(regionsB) {
+		if _, ok := regionsA[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("+ region %s", name))
+		}
+	}
+	for _, abbrev := range sortedKeys(cantonsA) {
+		cantonB, ok := cantonsB[abbrev]
+		if !ok {
+			continue
+		}
+		nameA, nameB := regionName(a, cantonsA[abbrev]), regionName(b, cantonB)
+		if nameA != nameB {
+			diffs = append(diffs, fmt.Sprintf("~ canton %s region %s -> %s", abbrev, nameA, nameB))
+		}
+	}
+
+	return diffs
+}
+
+func cantonsByAbbreviation(m *Map) map[string]*Canton {
+	cantons := make(map[string]*Canton, len(m.Cantons))
+	for _, canton := range m.Cantons {
+		cantons[canton.Abbreviation] = canton
+	}
+	return cantons
+}
+
+func regionsByName(m *Map) map[string]*Region {
+	regions := make(map[string]*Region, len(m.Regions))
+	for _, region := range m.Regions {
+		regions[region.Name] = region
+	}
+	return regions
+}
+
+func regionName(m *Map, canton *Canton) string {
+	if region, ok := m.Regions[canton.RegionID]; ok {
+		return region.Name
+	}
+	return "?"
+}
+
+// borders lists every border of the map as "A-B" with abbreviations in
+// alphabetical order, counting a border listed on either side
+func borders(m *Map) map[string]bool {
+	result := make(map[string]bool)
+	for _, canton := range m.Cantons {
+		for _, adjID := range canton.AdjacentIDs {
+			adj, ok := m.Cantons[adjID]
+			if !ok {
+				continue
+			}
+			pair := []string{canton.Abbreviation, adj.Abbreviation}
+			sort.Strings(pair)
+			result[pair[0]+"-"+pair[1]] = true
+		}
+	}
+	return result
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
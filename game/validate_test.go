@@ -0,0 +1,144 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMap builds a valid map of 3 cantons in a line (0-1-2) split over 2 regions
+func newTestMap() *Map {
+	m := NewMap()
+	m.Regions[1] = &Region{ID: 1, Name: "North", CantonIDs: []int{0, 1}, Bonus: 2}
+	m.Regions[2] = &Region{ID: 2, Name: "South", CantonIDs: []int{2}, Bonus: 1}
+	m.AddCanton(&Canton{ID: 0, Abbreviation: "A", RegionID: 1})
+	m.AddCanton(&Canton{ID: 1, Abbreviation: "B", RegionID: 1})
+	m.AddCanton(&Canton{ID: 2, Abbreviation: "C", RegionID: 2})
+	m.AddBorder(0, 1)
+	m.AddBorder(1, 2)
+	return m
+}
+
+func requireProblem(t *testing.T, err error, expected ValidationError) {
+	t.Helper()
+	errs, ok := err.(ValidationErrors)
+	require.True(t, ok, "Should return validation errors")
+	require.Contains(t, errs, expected)
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("accepts the built-in map", func(t *testing.T) {
+		require.NoError(t, CreateMap().Validate())
+	})
+
+	t.Run("accepts a valid map", func(t *testing.T) {
+		require.NoError(t, newTestMap().Validate())
+	})
+
+	t.Run("detects asymmetric borders", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[0].AdjacentIDs = append(m.Cantons[0].AdjacentIDs, 2)
+
+		requireProblem(t, m.Validate(), ValidationError{Kind: AsymmetricBorder, CantonID: 0, RegionID: -1, OtherID: 2})
+	})
+
+	t.Run("detects self loops", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[1].AdjacentIDs = append(m.Cantons[1].AdjacentIDs, 1)
+
+		requireProblem(t, m.Validate(), ValidationError{Kind: SelfLoop, CantonID: 1, RegionID: -1, OtherID: -1})
+	})
+
+	t.Run("detects unknown cantons", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[1].AdjacentIDs = append(m.Cantons[1].AdjacentIDs, 7)
+		m.Regions[2].CantonIDs = append(m.Regions[2].CantonIDs, 8)
+
+		err := m.Validate()
+
+		requireProblem(t, err, ValidationError{Kind: UnknownCanton, CantonID: 1, RegionID: -1, OtherID: 7})
+		requireProblem(t, err, ValidationError{Kind: UnknownCanton, CantonID: -1, RegionID: 2, OtherID: 8})
+	})
+
+	t.Run("detects disconnected cantons", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[1].AdjacentIDs = []int{0}
+		m.Cantons[2].AdjacentIDs = []int{}
+
+		requireProblem(t, m.Validate(), ValidationError{Kind: Disconnected, CantonID: 2, RegionID: -1, OtherID: -1})
+	})
+
+	t.Run("detects non-dense canton IDs", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[5] = m.Cantons[2]
+		m.Cantons[5].ID = 5
+		delete(m.Cantons, 2)
+
+		requireProblem(t, m.Validate(), ValidationError{Kind: NonDenseIDs, CantonID: 5, RegionID: -1, OtherID: -1})
+	})
+
+	t.Run("detects uncovered and overlapping regions", func(t *testing.T) {
+		m := newTestMap()
+		m.Regions[1].CantonIDs = []int{0}
+		m.Regions[2].CantonIDs = []int{0, 1, 2}
+
+		err := m.Validate()
+
+		requireProblem(t, err, ValidationError{Kind: UncoveredCanton, CantonID: 1, RegionID: 1, OtherID: -1})
+		requireProblem(t, err, ValidationError{Kind: OverlappingRegion, CantonID: 0, RegionID: 1, OtherID: 2})
+	})
+
+	t.Run("detects unknown regions", func(t *testing.T) {
+		m := newTestMap()
+		m.Cantons[2].RegionID = 3
+
+		requireProblem(t, m.Validate(), ValidationError{Kind: UnknownRegion, CantonID: 2, RegionID: -1, OtherID: 3})
+	})
+
+	t.Run("detects empty regions and non-positive bonuses", func(t *testing.T) {
+		m := newTestMap()
+		m.Regions[3] = &Region{ID: 3, Name: "Empty", Bonus: 0}
+
+		err := m.Validate()
+
+		requireProblem(t, err, ValidationError{Kind: EmptyRegion, CantonID: -1, RegionID: 3, OtherID: -1})
+		requireProblem(t, err, ValidationError{Kind: NonPositiveBonus, CantonID: -1, RegionID: 3, OtherID: -1})
+	})
+}
+
+func TestDiffMaps(t *testing.T) {
+	t.Run("finds no difference between identical maps", func(t *testing.T) {
+		require.Empty(t, DiffMaps(CreateMap(), CreateMap()))
+	})
+
+	t.Run("lists differing cantons, borders and regions", func(t *testing.T) {
+		a := newTestMap()
+		b := newTestMap()
+		b.Cantons[1].AdjacentIDs = []int{0}
+		b.Cantons[2].AdjacentIDs = []int{0}
+		b.Cantons[0].AdjacentIDs = []int{1, 2}
+		b.Regions[2].Bonus = 5
+		b.AddCanton(&Canton{ID: 3, Abbreviation: "D", RegionID: 2})
+
+		require.Equal(t, []string{
+			"+ canton D",
+			"- border B-C",
+			"+ border A-C",
+			"~ region South bonus 1 -> 5",
+		}, DiffMaps(a, b))
+	})
+}
+
+func TestLoadBorderList(t *testing.T) {
+	list := `'A': 'B', 'C'
+'B': 'A'
+'C' : 'B'
+`
+	m, err := LoadBorderList(strings.NewReader(list))
+
+	require.NoError(t, err)
+	require.Len(t, m.Cantons, 3)
+	requireProblem(t, m.Validate(), ValidationError{Kind: AsymmetricBorder, CantonID: 2, RegionID: -1, OtherID: 1})
+	require.Equal(t, []string{"+ border A-C"}, DiffMaps(newTestMap(), m), "Should count a border listed on either side")
+}
@@ -15,6 +15,10 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	log.Info().Msgf("number of CPUs: %d", runtime.NumCPU())
 
 	experiments.RunParallelismExperiment()
@@ -33,18 +33,27 @@ func StartAgentServer(port string) {
 
 func handleFindMove(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
-		State   game.GameState     `json:"state"`
-		Updates []searcher.Segment `json:"updates"`
+		State   game.GameState `json:"state"`
+		Updates []struct {
+			Move      *game.GameMove `json:"move"`
+			StateHash game.StateHash `json:"stateHash"`
+		} `json:"updates"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	if payload.State.Rules == nil {
-		payload.State.Rules = game.NewStandardRules()
+	// Moves are decoded into their concrete type so they compare equal to the moves of the search tree
+	updates := make([]searcher.Segment, len(payload.Updates))
+	for i, update := range payload.Updates {
+		if update.Move == nil {
+			http.Error(w, "bad request: update without a move", http.StatusBadRequest)
+			return
+		}
+		updates[i] = searcher.Segment{Move: update.Move, StateHash: update.StateHash}
 	}
 
-	chosenMove, _ := evalAgent.FindMove(&payload.State, payload.Updates...)
+	chosenMove, _ := evalAgent.FindMove(&payload.State, updates...)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(chosenMove); err != nil {
@@ -13,23 +13,41 @@ type evaluationAgent struct {
 }
 
 // NewEvaluationAgent returns a new agent for actual game play during evaluation.
+// Its search is left without root noise unless configured with it.
 func NewEvaluationAgent(mcts *searcher.MCTS) Agent {
 	return evaluationAgent{mcts: mcts}
 }
 
 func (a evaluationAgent) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
 	policy, metric := a.mcts.Simulate(state, updates)
-	move := findMax(policy)
+	move := findMax(state, policy)
 	return move, metric
 }
 
-func findMax(policy map[game.Move]float64) game.Move {
+// findMax returns the most visited move of the policy. Ties go to the move
+// generated first by the state, so equal policies always yield the same move.
+func findMax(state game.State, policy map[game.Move]float64) game.Move {
+	order := make(map[game.GameMove]int)
+	for i, move := range state.LegalMoves() {
+		if gameMove, ok := move.(*game.GameMove); ok {
+			order[*gameMove] = i
+		}
+	}
+	index := func(move game.Move) int {
+		if gameMove, ok := move.(*game.GameMove); ok {
+			if i, ok := order[*gameMove]; ok {
+				return i
+			}
+		}
+		return len(order)
+	}
+
 	var maxMove game.Move
 	maxVisit := -1.0
 	var moves []game.Move
 	var visits []float64
 	for move, visit := range policy {
-		if visit > maxVisit {
+		if visit > maxVisit || (visit == maxVisit && index(move) < index(maxMove)) {
 			maxVisit = visit
 			maxMove = move
 		}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"risk/game"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMax(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	moves := state.LegalMoves()
+	require.Greater(t, len(moves), 3)
+
+	// Copies of the legal moves, as a search tree built from another state of the same game holds
+	policyOf := func(visits ...float64) map[game.Move]float64 {
+		policy := make(map[game.Move]float64, len(moves))
+		for i, move := range moves {
+			gameMove := *move.(*game.GameMove)
+			policy[&gameMove] = 1
+			if i < len(visits) {
+				policy[&gameMove] = visits[i]
+			}
+		}
+		return policy
+	}
+
+	t.Run("picks the most visited move", func(t *testing.T) {
+		require.Equal(t, moves[2], findMax(state, policyOf(3, 1, 5, 4)))
+	})
+
+	t.Run("breaks ties by the order of the legal moves", func(t *testing.T) {
+		for range 20 {
+			require.Equal(t, moves[1], findMax(state, policyOf(1, 4, 2, 4, 4)))
+		}
+	})
+}
@@ -12,9 +12,22 @@ type trainingAgent struct {
 	mcts *searcher.MCTS
 }
 
-// NewTrainingAgent returns a new agent for self-play during training.
+// NewTrainingAgent returns a new agent for self-play during training, searching
+// with a copy of the given search. Unless configured with root noise already,
+// the copy mixes Dirichlet noise into the priors of the root, with a
+// concentration scaled to the number of legal moves. As noise only steers a
+// tree policy reading priors, the copy then also replaces a tree policy that
+// does not, such as the default UCT, with PUCT. The given search is left
+// unchanged, and a search configured with root noise is copied as is.
 func NewTrainingAgent(mcts *searcher.MCTS) Agent {
-	return trainingAgent{mcts: mcts}
+	var options []searcher.Option
+	if !mcts.HasRootNoise() {
+		options = append(options, searcher.WithRootNoise(0, searcher.NoiseWeight))
+		if !mcts.ReadsPriors() {
+			options = append(options, searcher.WithTreePolicy(searcher.PUCT{CPuct: searcher.CPuct}))
+		}
+	}
+	return trainingAgent{mcts: mcts.Clone(options...)}
 }
 
 func (a trainingAgent) FindMove(state game.State, updates ...searcher.Segment) (game.Move, metrics.SearchMetric) {
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"risk/game"
+	"risk/searcher"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrainingAgent(t *testing.T) {
+	t.Run("searches with root noise under PUCT", func(t *testing.T) {
+		mcts := searcher.NewMCTS(1, searcher.WithEpisodes(10), searcher.WithSeed(1))
+
+		agent := NewTrainingAgent(mcts).(trainingAgent)
+
+		require.True(t, agent.mcts.HasRootNoise())
+		require.Equal(t, searcher.PUCT{CPuct: searcher.CPuct}, agent.mcts.TreePolicy())
+		require.False(t, mcts.HasRootNoise(), "Should not change the search given")
+		require.Equal(t, searcher.UCT{CSquared: searcher.CSquared}, mcts.TreePolicy(), "Should not change the search given")
+	})
+
+	t.Run("keeps a tree policy reading priors", func(t *testing.T) {
+		mcts := searcher.NewMCTS(1, searcher.WithEpisodes(10), searcher.WithSeed(1), searcher.WithTreePolicy(searcher.PUCT{CPuct: 3}))
+
+		agent := NewTrainingAgent(mcts).(trainingAgent)
+
+		require.True(t, agent.mcts.HasRootNoise())
+		require.Equal(t, searcher.PUCT{CPuct: 3}, agent.mcts.TreePolicy())
+	})
+
+	t.Run("keeps the root noise and tree policy configured", func(t *testing.T) {
+		mcts := searcher.NewMCTS(1, searcher.WithEpisodes(10), searcher.WithSeed(1), searcher.WithRootNoise(0.3, 0.5), searcher.WithTreePolicy(searcher.UCB1Tuned{}))
+
+		agent := NewTrainingAgent(mcts).(trainingAgent)
+
+		require.Equal(t, searcher.UCB1Tuned{}, agent.mcts.TreePolicy())
+	})
+
+	t.Run("plays legal moves", func(t *testing.T) {
+		state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+		agent := NewTrainingAgent(searcher.NewMCTS(1, searcher.WithEpisodes(20), searcher.WithCutoff(10), searcher.WithSeed(1)))
+
+		move, _ := agent.FindMove(state)
+
+		require.NoError(t, state.ValidateMove(move))
+	})
+}
+
+func TestNewEvaluationAgent(t *testing.T) {
+	mcts := searcher.NewMCTS(1, searcher.WithEpisodes(10))
+
+	agent := NewEvaluationAgent(mcts).(evaluationAgent)
+
+	require.Same(t, mcts, agent.mcts, "Should search with the search given")
+	require.False(t, agent.mcts.HasRootNoise(), "Should search without root noise")
+	require.Equal(t, searcher.UCT{CSquared: searcher.CSquared}, agent.mcts.TreePolicy(), "Should keep the tree policy")
+}
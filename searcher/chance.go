@@ -1,29 +1,33 @@
 package searcher
 
 import (
+	"math/rand"
 	"risk/game"
 	"sync"
 )
 
 type chance struct {
 	sync.RWMutex
-	parent   Node
-	player   string
-	children []*decision
-	rewards  float64
-	visits   float64
+	parent    Node
+	player    string // Player whose stochastic move led to the node, from whose perspective rewards are kept
+	children  []*decision
+	rewards   float64
+	squares   float64 // Sum of squared rewards
+	visits    float64
+	selection *selection
 }
 
 func newChance(parent *decision) *chance {
 	return &chance{
-		parent:  parent,
-		player:  parent.player,
-		rewards: 0,
-		visits:  0,
+		parent:    parent,
+		player:    parent.player,
+		selection: parent.selection,
+		rewards:   0,
+		visits:    0,
 	}
 }
 
-func (c *chance) SelectOrExpand(state game.State) (Node, game.State, bool) {
+func (c *chance) SelectOrExpand(state game.State, rng *rand.Rand) (Node, game.State, bool) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -50,7 +54,7 @@ func (c *chance) selects(hash game.StateHash) *decision {
 }
 
 func (c *chance) expands(state game.State) *decision {
-	child := newDecision(c, state)
+	child := newDecision(c, c.player, state, c.selection)
 	c.children = append(c.children, child)
 	return child
 }
@@ -60,14 +64,15 @@ func (c *chance) applyLoss() {
 	defer c.Unlock()
 
 	c.rewards += Loss
+	c.squares += Loss * Loss
 	c.visits++
 }
 
-func (c *chance) stats() (player string, rewards float64, visits float64) {
+func (c *chance) stats() ChildStats {
 	c.RLock()
 	defer c.RUnlock()
 
-	return c.player, c.rewards, c.visits
+	return ChildStats{Rewards: c.rewards, Squares: c.squares, Visits: c.visits}
 }
 
 func (c *chance) Backup(player string, score float64) Node {
@@ -76,7 +81,9 @@ func (c *chance) Backup(player string, score float64) Node {
 
 	c.reverseLoss()
 
-	c.rewards += computeReward(player, score, c.player)
+	reward := computeReward(player, score, c.player)
+	c.rewards += reward
+	c.squares += reward * reward
 	c.visits++
 
 	return c.parent
@@ -84,6 +91,7 @@ func (c *chance) Backup(player string, score float64) Node {
 
 func (c *chance) reverseLoss() {
 	c.rewards -= Loss
+	c.squares -= Loss * Loss
 	c.visits--
 }
 
@@ -15,7 +15,7 @@ func TestChanceSelectOrExpand(t *testing.T) {
 		}
 		state := mockState{player: "player1", hash: 2}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.IsType(t, &decision{}, gotChild, "Child should be a decision node")
 		require.Equal(t, Loss, gotChild.(*decision).rewards, "Child should apply a temporary loss")
@@ -35,9 +35,9 @@ func TestChanceSelectOrExpand(t *testing.T) {
 
 		// Expand first then select
 		state := mockState{player: "player1", hash: 2}
-		otherChild, _, _ := node.SelectOrExpand(state)
+		otherChild, _, _ := node.SelectOrExpand(state, newRNG())
 		state = mockState{player: "player1", hash: 2}
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.IsType(t, &decision{}, gotChild, "Child should be a decision node")
 		require.Equal(t, Loss*2, gotChild.(*decision).rewards, "Child should apply 2 temporary losses")
@@ -3,6 +3,7 @@ package searcher
 import (
 	// "fmt"
 	"math"
+	"math/rand"
 	"risk/game"
 	"sync"
 
@@ -12,30 +13,42 @@ import (
 type decision struct {
 	sync.RWMutex
 	parent     Node
-	player     string
+	player     string // Player to move
+	mover      string // Player whose move led to the node, from whose perspective rewards are kept
 	unexplored []game.Move
 	explored   []game.Move
 	children   []Node
 	hash       game.StateHash
 	rewards    float64
+	squares    float64 // Sum of squared rewards
 	visits     float64
+	selection  *selection
+	priors     []float64 // Prior of the explored then unexplored moves, if the tree has a prior
+	biases     []float64 // Heuristic score of the explored moves, if the tree policy is Biased
 }
 
-func newDecision(parent Node, state game.State) *decision {
+func newDecision(parent Node, mover string, state game.State, selection *selection) *decision {
 	moves := state.LegalMoves()
 	movesCopy := make([]game.Move, len(moves))
 	copy(movesCopy, moves)
 
-	return &decision{
+	d := &decision{
 		parent:     parent,
 		player:     state.Player(),
+		mover:      mover,
 		unexplored: movesCopy,
 		explored:   make([]game.Move, 0, len(movesCopy)),
 		children:   make([]Node, 0, len(movesCopy)),
 		hash:       state.Hash(),
 		rewards:    0,
 		visits:     0,
+		selection:  selection,
 	}
+	if selection != nil && selection.prior != nil && len(movesCopy) > 0 {
+		// Order moves to expand them from the most probable
+		d.priors = sortByPrior(movesCopy, selection.prior.Probabilities(state, movesCopy))
+	}
+	return d
 }
 
 // SelectOrExpand
@@ -43,7 +56,7 @@ func newDecision(parent Node, state game.State) *decision {
 // - if not fully expanded, expand the node by adding a child node for an unexplored move
 // - in both cases, advance the state by playing the move to the child node
 // - if terminal, simply return the node itself with the state unchanged
-func (d *decision) SelectOrExpand(state game.State) (Node, game.State, bool) {
+func (d *decision) SelectOrExpand(state game.State, rng *rand.Rand) (Node, game.State, bool) {
 	d.Lock()
 	defer d.Unlock()
 
@@ -58,7 +71,7 @@ func (d *decision) SelectOrExpand(state game.State) (Node, game.State, bool) {
 	var child Node
 	selected := false
 	if len(d.unexplored) > 0 { // Expand node with an unexplored move
-		child, state = d.expands(state)
+		child, state = d.expands(state, rng)
 	} else { // Select a child of fully expanded node
 		child, state = d.selects(state)
 		selected = true
@@ -68,19 +81,23 @@ func (d *decision) SelectOrExpand(state game.State) (Node, game.State, bool) {
 	return child, state, selected
 }
 
-func (d *decision) expands(state game.State) (Node, game.State) {
-	// Expand a random move
-	rng := newRNG()
-	index := rng.Intn(len(d.unexplored)) // move := d.moves[0]
+func (d *decision) expands(state game.State, rng *rand.Rand) (Node, game.State) {
+	index := 0           // Expand the most probable move
+	if d.priors == nil { // Expand a random move
+		index = rng.Intn(len(d.unexplored))
+	}
 	move := d.unexplored[index]
 
 	newState := state.Play(move)
 
 	var child Node
-	if move.IsStochastic() {
+	if isStochastic(state, move) {
 		child = newChance(d)
 	} else {
-		child = newDecision(d, newState)
+		child = newDecision(d, d.player, newState, d.selection)
+	}
+	if biased, ok := d.selection.treePolicy().(Biased); ok { // Stochastic moves are scored by the outcome played
+		d.biases = append(d.biases, scoreOutcome(newState, biased.Heuristic(), d.player))
 	}
 	d.children = append(d.children, child)
 	d.explored = append(d.explored, move)
@@ -91,6 +108,20 @@ func (d *decision) expands(state game.State) (Node, game.State) {
 	return child, newState
 }
 
+// stochasticState is implemented by states that tell whether their moves
+// depend on chance better than the moves themselves, such as by their rules
+type stochasticState interface {
+	IsStochastic(move game.Move) bool
+}
+
+// isStochastic tells whether a move of a state leads to a chance node
+func isStochastic(state game.State, move game.Move) bool {
+	if s, ok := state.(stochasticState); ok {
+		return s.IsStochastic(move)
+	}
+	return move.IsStochastic()
+}
+
 func (d *decision) selects(state game.State) (Node, game.State) {
 	if len(d.children) == 0 {
 		panic("no children")
@@ -102,7 +133,7 @@ func (d *decision) selects(state game.State) (Node, game.State) {
 		parentVisits = float64(len(d.children))
 	}
 
-	policy := newUCT(CSquared, parentVisits)
+	policy := d.selection.treePolicy()
 	maxValue := math.Inf(-1)
 	var maxMove game.Move
 	var maxChild Node
@@ -110,23 +141,27 @@ func (d *decision) selects(state game.State) (Node, game.State) {
 	var childRewards []float64
 	var childValues []float64
 	for i, child := range d.children {
-		player, rewards, visits := child.stats()
-		if visits == 0 {
+		// Children keep rewards from the perspective of this node's player,
+		// whose chance of winning is maximized
+		stats := child.stats()
+		if stats.Visits == 0 {
 			// Child should have virtual loss or backed up result
 			panic("unexplored child node (0 visits)")
 		}
-		// Maximize my chance of winning or if turn changes, minimize the opponent's
-		if player != d.player {
-			rewards = -rewards // Negate opponent's rewards
+		if d.priors != nil {
+			stats.Prior = d.priors[i]
+		}
+		if d.biases != nil {
+			stats.Bias = d.biases[i]
 		}
-		value := policy.evaluate(rewards, visits)
+		value := policy.Value(parentVisits, stats)
 		if value > maxValue {
 			maxValue = value
 			maxMove = d.explored[i]
 			maxChild = child
 		}
-		childVisits = append(childVisits, visits)
-		childRewards = append(childRewards, rewards)
+		childVisits = append(childVisits, stats.Visits)
+		childRewards = append(childRewards, stats.Rewards)
 		childValues = append(childValues, value)
 	}
 	if maxMove == nil { // TODO: remove
@@ -140,14 +175,15 @@ func (d *decision) applyLoss() {
 	defer d.Unlock()
 
 	d.rewards += Loss
+	d.squares += Loss * Loss
 	d.visits++
 }
 
-func (d *decision) stats() (player string, rewards float64, visits float64) {
+func (d *decision) stats() ChildStats {
 	d.RLock()
 	defer d.RUnlock()
 
-	return d.player, d.rewards, d.visits
+	return ChildStats{Rewards: d.rewards, Squares: d.squares, Visits: d.visits}
 }
 
 func (d *decision) Backup(player string, score float64) Node {
@@ -158,7 +194,9 @@ func (d *decision) Backup(player string, score float64) Node {
 		d.reverseLoss()
 	}
 
-	d.rewards += computeReward(player, score, d.player)
+	reward := computeReward(player, score, d.mover)
+	d.rewards += reward
+	d.squares += reward * reward
 	d.visits++
 
 	return d.parent
@@ -166,6 +204,7 @@ func (d *decision) Backup(player string, score float64) Node {
 
 func (d *decision) reverseLoss() {
 	d.rewards -= Loss
+	d.squares -= Loss * Loss
 	d.visits--
 }
 
@@ -175,7 +214,7 @@ func (d *decision) Policy() map[game.Move]float64 {
 
 	visits := make(map[game.Move]float64, len(d.children))
 	for i, child := range d.children {
-		_, _, visits[d.explored[i]] = child.stats()
+		visits[d.explored[i]] = child.stats().Visits
 	}
 
 	if len(visits) == 0 {
@@ -2,6 +2,7 @@ package searcher
 
 import (
 	"risk/game"
+	"slices"
 	"sync"
 	"testing"
 
@@ -46,7 +47,7 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 		}
 		state := mockState{}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.Equal(t, maxChild, gotChild, "Node should select child with max policy value")
 		require.IsType(t, &decision{}, gotChild,
@@ -73,7 +74,7 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 		}
 		state := mockState{}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.Equal(t, maxChild, gotChild, "Node should select child with max policy value")
 		require.IsType(t, &chance{}, gotChild,
@@ -88,54 +89,54 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 	})
 
 	t.Run("selecting fully expanded node (all deterministic moves explored) with turn change", func(t *testing.T) {
-		minMove := mockMove{id: 1}
-		minChild := &decision{player: "player2", rewards: 0, visits: 1}
-		otherChild := &decision{player: "player2", rewards: 1, visits: 1}
+		maxMove := mockMove{id: 1}
+		maxChild := &decision{player: "player2", mover: "player1", rewards: 1, visits: 1}
+		otherChild := &decision{player: "player2", mover: "player1", rewards: 0, visits: 1}
 		node := &decision{
 			player:     "player1",
 			unexplored: []game.Move{},
-			explored:   []game.Move{mockMove{id: 0}, minMove},
-			children:   []Node{otherChild, minChild},
+			explored:   []game.Move{mockMove{id: 0}, maxMove},
+			children:   []Node{otherChild, maxChild},
 			rewards:    1,
 			visits:     2,
 		}
 		state := mockState{}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
-		require.Equal(t, minChild, gotChild, "Node should select child with max policy value that minimizes opponent rewards")
+		require.Equal(t, maxChild, gotChild, "Node should select child with max policy value from its own perspective")
 		require.IsType(t, &decision{}, gotChild, "Child should be a decision node")
-		require.Equal(t, Loss, gotChild.(*decision).rewards, "Child should apply a temporary loss")
+		require.Equal(t, 1+Loss, gotChild.(*decision).rewards, "Child should apply a temporary loss")
 		require.Equal(t, 2.0, gotChild.(*decision).visits,
 			"Child should apply a temporary loss")
-		require.Equal(t, []game.Move{minMove}, gotState.(mockState).played, "State should update by the move to the max policy child")
+		require.Equal(t, []game.Move{maxMove}, gotState.(mockState).played, "State should update by the move to the max policy child")
 		require.True(t, gotSelected, "Node should perform selection")
 		require.Equal(t, 1.0, node.rewards, "Node stats should not change")
 		require.Equal(t, 2.0, node.visits, "Node stats should not change")
 	})
 
 	t.Run("selecting fully expanded node (all stochastic moves explored) with turn change", func(t *testing.T) {
-		minMove := mockMove{id: 1, stochastic: true}
-		minChild := &chance{player: "player2", rewards: 0, visits: 1}
-		otherChild := &chance{player: "player2", rewards: 1, visits: 1}
+		maxMove := mockMove{id: 1, stochastic: true}
+		maxChild := &chance{player: "player1", rewards: 1, visits: 1}
+		otherChild := &chance{player: "player1", rewards: 0, visits: 1}
 		node := &decision{
 			player:     "player1",
 			unexplored: []game.Move{},
-			explored:   []game.Move{mockMove{id: 0, stochastic: true}, minMove},
-			children:   []Node{otherChild, minChild},
+			explored:   []game.Move{mockMove{id: 0, stochastic: true}, maxMove},
+			children:   []Node{otherChild, maxChild},
 			rewards:    1,
 			visits:     2,
 		}
 		state := mockState{}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
-		require.Equal(t, minChild, gotChild, "Node should select child with max policy value that minimizes opponent rewards")
+		require.Equal(t, maxChild, gotChild, "Node should select child with max policy value from its own perspective")
 		require.IsType(t, &chance{}, gotChild, "Child should be a chance node")
-		require.Equal(t, Loss, gotChild.(*chance).rewards, "Child should apply a temporary loss")
+		require.Equal(t, 1+Loss, gotChild.(*chance).rewards, "Child should apply a temporary loss")
 		require.Equal(t, 2.0, gotChild.(*chance).visits,
 			"Child should apply a temporary loss")
-		require.Equal(t, []game.Move{minMove}, gotState.(mockState).played, "State should update by the move to the max policy child")
+		require.Equal(t, []game.Move{maxMove}, gotState.(mockState).played, "State should update by the move to the max policy child")
 		require.True(t, gotSelected, "Node should perform selection")
 		require.Equal(t, 1.0, node.rewards, "Node stats should not change")
 		require.Equal(t, 2.0, node.visits, "Node stats should not change")
@@ -151,7 +152,7 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 		}
 		state := mockState{moves: []game.Move{}}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.IsType(t, &decision{}, gotChild,
 			"Child should be a decision node")
@@ -174,7 +175,7 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 		}
 		state := mockState{moves: []game.Move{}}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.IsType(t, &chance{}, gotChild,
 			"Child should be a chance node")
@@ -187,11 +188,71 @@ func TestDecisionSelectOrExpand(t *testing.T) {
 		require.Equal(t, 2, len(node.children), "Node should add a new child")
 	})
 
+	t.Run("expanding attack declarations to decision nodes when defenders choose their dice", func(t *testing.T) {
+		rules := game.NewStandardRules()
+		rules.DefenderDice = true
+		state := game.NewGameState(game.CreateMap(), rules, game.WithPlayers(3), game.WithSeed(1))
+		for state.Phase != game.AttackPhase {
+			state = state.Play(state.LegalMoves()[0]).(*game.GameState)
+		}
+		node := newDecision(nil, "", state, nil)
+		attack := slices.IndexFunc(node.unexplored, func(move game.Move) bool {
+			return move.(*game.GameMove).ActionType == game.AttackAction
+		})
+		require.GreaterOrEqual(t, attack, 0, "Should be able to attack")
+		node.unexplored = node.unexplored[attack : attack+1]
+
+		gotChild, gotState, _ := node.SelectOrExpand(state, newRNG())
+
+		require.IsType(t, &decision{}, gotChild, "Declaring an attack should roll no dice")
+		require.Equal(t, game.DefendPhase, gotState.(*game.GameState).Phase)
+		require.Equal(t, gotState.Player(), gotChild.(*decision).player, "Defender should choose its dice next")
+		require.NotEqual(t, node.player, gotChild.(*decision).player)
+	})
+
+	t.Run("selecting by PUCT favors the more probable move", func(t *testing.T) {
+		probableMove := mockMove{id: 1}
+		probableChild := &decision{rewards: 0, visits: 1}
+		otherChild := &decision{rewards: 0, visits: 1}
+		node := &decision{
+			unexplored: []game.Move{},
+			explored:   []game.Move{mockMove{id: 0}, probableMove},
+			children:   []Node{otherChild, probableChild},
+			rewards:    1,
+			visits:     2,
+			selection:  &selection{policy: PUCT{CPuct: CPuct}},
+			priors:     []float64{0.2, 0.8},
+		}
+		state := mockState{}
+
+		gotChild, gotState, _ := node.SelectOrExpand(state, newRNG())
+
+		require.Same(t, probableChild, gotChild, "Node should select the child of higher prior given equal stats")
+		require.Equal(t, []game.Move{probableMove}, gotState.(mockState).played)
+	})
+
+	t.Run("expanding the most probable move first", func(t *testing.T) {
+		moves := []game.Move{mockMove{id: 0}, mockMove{id: 1}, mockMove{id: 2}}
+		prior := mockPrior{0.2, 0.5, 0.3}
+		node := newDecision(nil, "player1", mockState{moves: moves}, &selection{prior: prior})
+
+		require.Equal(t, []game.Move{mockMove{id: 1}, mockMove{id: 2}, mockMove{id: 0}}, node.unexplored, "Node should order moves by prior")
+		require.Equal(t, []float64{0.5, 0.3, 0.2}, node.priors)
+
+		for _, expected := range []game.Move{mockMove{id: 1}, mockMove{id: 2}, mockMove{id: 0}} {
+			_, gotState, gotSelected := node.SelectOrExpand(mockState{}, newRNG())
+
+			require.False(t, gotSelected, "Node should perform expansion")
+			require.Equal(t, []game.Move{expected}, gotState.(mockState).played, "Node should expand moves from the most probable")
+		}
+		require.Equal(t, []game.Move{mockMove{id: 1}, mockMove{id: 2}, mockMove{id: 0}}, node.explored, "Explored moves should keep the order of their priors")
+	})
+
 	t.Run("stagnating on terminal node", func(t *testing.T) {
 		node := &decision{}
 		state := mockState{}
 
-		gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+		gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 
 		require.Equal(t, node, gotChild, "Should return the same node")
 		require.Equal(t, mockState{}, gotState, "Should return the same state")
@@ -205,6 +266,7 @@ func TestDecisionBackup(t *testing.T) {
 		node := &decision{
 			parent:  nil,
 			player:  "player1",
+			mover:   "player1",
 			rewards: 0,
 			visits:  0,
 		}
@@ -222,6 +284,7 @@ func TestDecisionBackup(t *testing.T) {
 		node := &decision{
 			parent:  parent,
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss,
 			visits:  1,
 		}
@@ -234,12 +297,27 @@ func TestDecisionBackup(t *testing.T) {
 			"Should reverse virtual loss and add a visit")
 	})
 
+	t.Run("recording squared rewards for their variance", func(t *testing.T) {
+		parent := &decision{}
+		node := &decision{parent: parent, player: "player1", mover: "player1"}
+
+		node.applyLoss()
+		node.Backup("player1", 0.5)
+		node.applyLoss()
+		node.Backup("player2", 0.5)
+
+		require.Equal(t, 2.0, node.visits)
+		require.Equal(t, 0.0, node.rewards)
+		require.Equal(t, 0.5, node.squares, "Should reverse virtual losses and add squared rewards")
+	})
+
 	t.Run("recording win on stochastic outcome node", func(t *testing.T) {
 		// Setup a node with chance parent and a virtual loss
 		parent := &chance{}
 		node := &decision{
 			parent:  parent,
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss,
 			visits:  1,
 		}
@@ -258,6 +336,7 @@ func TestDecisionBackup(t *testing.T) {
 		node := &decision{
 			parent:  parent,
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss,
 			visits:  1,
 		}
@@ -276,6 +355,7 @@ func TestDecisionBackup(t *testing.T) {
 		node := &decision{
 			parent:  parent,
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss,
 			visits:  1,
 		}
@@ -286,6 +366,42 @@ func TestDecisionBackup(t *testing.T) {
 		require.Equal(t, Loss, node.rewards, "Should reverse virtual loss and add a loss")
 		require.Equal(t, 1.0, node.visits, "Should reverse virtual loss and add a visit")
 	})
+
+	t.Run("recording win of the player who moved into a node of another player", func(t *testing.T) {
+		// Setup a node where the turn passed to player2 after player1 moved
+		parent := &decision{player: "player1"}
+		node := &decision{
+			parent:  parent,
+			player:  "player2",
+			mover:   "player1",
+			rewards: Loss,
+			visits:  1,
+		}
+
+		got := node.Backup("player1", Win)
+
+		require.Equal(t, parent, got, "Should return the parent node")
+		require.Equal(t, Win, node.rewards, "Should reward the player who moved into the node")
+		require.Equal(t, 1.0, node.visits, "Should reverse virtual loss and add a visit")
+	})
+
+	t.Run("recording loss of the player who moved into a node when a third player wins", func(t *testing.T) {
+		// Setup a node where player2 defends against player1 in a three player game
+		parent := &decision{player: "player1"}
+		node := &decision{
+			parent:  parent,
+			player:  "player2",
+			mover:   "player1",
+			rewards: Loss,
+			visits:  1,
+		}
+
+		got := node.Backup("player3", Win)
+
+		require.Equal(t, parent, got, "Should return the parent node")
+		require.Equal(t, Loss, node.rewards, "Should count a third player's win against the player who moved into the node")
+		require.Equal(t, 1.0, node.visits, "Should reverse virtual loss and add a visit")
+	})
 }
 
 func TestDecisionRaceConditions(t *testing.T) {
@@ -316,7 +432,7 @@ func TestDecisionRaceConditions(t *testing.T) {
 				defer wg.Done()
 				// Each goroutine gets its own copy of state
 				state := mockState{moves: baseState.moves}
-				gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+				gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 				got[i] = result{gotChild, gotState.(mockState), gotSelected}
 			}()
 		}
@@ -352,6 +468,7 @@ func TestDecisionRaceConditions(t *testing.T) {
 		node := &decision{
 			parent:  parent, // Non-root
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss * 2, // 2 virtual losses
 			visits:  2,        // 2 virtual losses
 		}
@@ -382,6 +499,7 @@ func TestDecisionRaceConditions(t *testing.T) {
 		node := &decision{
 			parent:  parent, // Non-root
 			player:  "player1",
+			mover:   "player1",
 			rewards: Loss, // Virtual loss
 			visits:  3,    // Virtual loss
 		}
@@ -402,7 +520,7 @@ func TestDecisionRaceConditions(t *testing.T) {
 		// Goroutine 1: Select the child
 		go func() {
 			defer wg.Done()
-			gotChild, gotState, gotSelected := node.SelectOrExpand(state)
+			gotChild, gotState, gotSelected := node.SelectOrExpand(state, newRNG())
 			require.Equal(t, child, gotChild,
 				"Node should select the child")
 			require.Equal(t, move, gotState.(mockState).played[0],
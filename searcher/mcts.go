@@ -1,8 +1,10 @@
 package searcher
 
 import (
+	"math/rand"
 	"risk/experiments/metrics"
 	"risk/game"
+	"slices"
 	"sync"
 	"time"
 
@@ -17,13 +19,19 @@ type Segment struct {
 }
 
 type MCTS struct {
-	goroutines int
-	duration   time.Duration
-	episodes   int
-	cutoff     int
-	evaluate   game.Evaluate
-	root       *decision
-	metrics    metrics.Collector
+	goroutines    int
+	duration      time.Duration
+	episodes      int
+	cutoff        int
+	evaluate      game.Evaluate
+	root          *decision
+	metrics       metrics.Collector
+	rng           *rand.Rand // Seeds the random sources of the goroutines of each search
+	selection     *selection
+	noise         *rootNoise // Mixed into the priors of the root if set
+	rolloutPolicy RolloutPolicy
+
+	copyRollouts bool // Roll out by copying states even if they can be played in place
 }
 
 func WithDuration(duration time.Duration) Option {
@@ -58,6 +66,73 @@ func WithEvaluationFn(evaluate game.Evaluate) Option {
 	}
 }
 
+// WithSeed seeds the search so that, run with a single goroutine and a fixed
+// number of episodes, it explores the same tree every time
+func WithSeed(seed int64) Option {
+	return func(m *MCTS) {
+		m.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithTreePolicy selects the children of fully expanded nodes by the policy
+// instead of UCT
+func WithTreePolicy(policy TreePolicy) Option {
+	return func(m *MCTS) {
+		if policy != nil {
+			m.selection.policy = policy
+		}
+	}
+}
+
+// WithSelectionPolicy selects the children of fully expanded nodes by UCT or
+// PUCT, weighing exploration by the given constant, c^2 of UCT or c_puct of PUCT,
+// or the policy's default if not positive
+func WithSelectionPolicy(policy SelectionPolicy, exploration float64) Option {
+	if policy == PUCTSelection {
+		if exploration <= 0 {
+			exploration = CPuct
+		}
+		return WithTreePolicy(PUCT{CPuct: exploration})
+	}
+	if exploration <= 0 {
+		exploration = CSquared
+	}
+	return WithTreePolicy(UCT{CSquared: exploration})
+}
+
+// WithPrior expands the moves of nodes from the most probable according to the
+// prior, which also weighs the exploration of moves under PUCT
+func WithPrior(prior Prior) Option {
+	return func(m *MCTS) {
+		m.selection.prior = prior
+	}
+}
+
+// WithRootNoise mixes Dirichlet noise of concentration alpha into the priors of
+// the root of every search with weight epsilon, so that self-play explores moves
+// the prior overlooks. Alpha is scaled to the number of legal moves if not
+// positive, and noise is turned off if epsilon is not positive. Without a prior,
+// the noise perturbs a uniform one.
+func WithRootNoise(alpha, epsilon float64) Option {
+	return func(m *MCTS) {
+		if epsilon <= 0 {
+			m.noise = nil
+			return
+		}
+		m.noise = &rootNoise{alpha: alpha, epsilon: epsilon}
+	}
+}
+
+// WithRolloutPolicy chooses the moves of rollouts with the policy instead of
+// uniformly at random
+func WithRolloutPolicy(policy RolloutPolicy) Option {
+	return func(m *MCTS) {
+		if policy != nil {
+			m.rolloutPolicy = policy
+		}
+	}
+}
+
 func WithMetrics() Option {
 	return func(m *MCTS) {
 		m.metrics = metrics.NewCollector()
@@ -66,32 +141,88 @@ func WithMetrics() Option {
 
 func NewMCTS(goroutines int, options ...Option) *MCTS {
 	m := &MCTS{ // Default values
-		goroutines: goroutines,
-		cutoff:     MaxCutoff,
-		evaluate:   game.EvaluateResources,
-		metrics:    metrics.NewDummyCollector(),
-	}
-	for _, option := range options {
-		option(m)
+		goroutines:    goroutines,
+		cutoff:        MaxCutoff,
+		evaluate:      game.EvaluateResources,
+		metrics:       metrics.NewDummyCollector(),
+		rng:           newRNG(),
+		selection:     &selection{policy: UCT{CSquared: CSquared}},
+		rolloutPolicy: UniformRollout{},
 	}
+	m.Configure(options...)
 	if m.episodes <= 0 && m.duration <= 0 {
 		panic("Must specify search episodes or duration")
 	}
 	return m
 }
 
+// Configure applies options to the search, as agents do to adapt it to their use
+func (m *MCTS) Configure(options ...Option) {
+	for _, option := range options {
+		option(m)
+	}
+	readsPriors := m.ReadsPriors()
+	if readsPriors && m.selection.prior == nil {
+		m.selection.prior = UniformPrior{}
+	}
+	if m.noise != nil && !readsPriors {
+		log.Warn().Msgf("root noise only reorders the expansion of moves under tree policy %T, which ignores priors", m.selection.policy)
+	}
+}
+
+// Clone returns a search configured like this one, with options applied on
+// top, that starts from a fresh tree and draws from its own source of
+// randomness seeded from this search's. It shares the metrics collector.
+func (m *MCTS) Clone(options ...Option) *MCTS {
+	clone := *m
+	clone.root = nil
+	clone.rng = rand.New(rand.NewSource(m.rng.Int63()))
+	selection := *m.selection
+	clone.selection = &selection
+	clone.Configure(options...)
+	return &clone
+}
+
+// HasRootNoise tells whether noise is mixed into the priors of the root
+func (m *MCTS) HasRootNoise() bool {
+	return m.noise != nil
+}
+
+// TreePolicy returns the policy selecting the children of fully expanded nodes
+func (m *MCTS) TreePolicy() TreePolicy {
+	return m.selection.policy
+}
+
+// ReadsPriors tells whether the tree policy weighs children by their priors, so
+// that priors and root noise steer exploration rather than only the order of
+// expansion
+func (m *MCTS) ReadsPriors() bool {
+	_, ok := m.selection.policy.(PUCT)
+	return ok
+}
+
+// Simulate searches the state, reusing the tree of the previous search if the
+// lineage of moves played since leads from its root to the state
 func (m *MCTS) Simulate(state game.State, lineage []Segment) (map[game.Move]float64, metrics.SearchMetric) {
-	root := newDecision(nil, state)
-	m.metrics.SetTreeReset(true)
+	// Find the root from a randomized state, as priors may play moves
+	states := m.randomize(state)
+	root := m.findRoot(states[0].state, lineage)
+	if m.noise != nil {
+		prior := m.selection.prior
+		if prior == nil {
+			prior = UniformPrior{}
+		}
+		m.noise.apply(root, states[0].state, prior, m.rng)
+	}
 
 	// log.Warn().Msgf("root start %p: %+v", root, root)
 
 	// Run simulations to collect statistics
 	m.metrics.Start(m.goroutines, m.cutoff, m.evaluate)
 	if m.episodes > 0 {
-		m.iterate(root, state)
+		m.iterate(root, states)
 	} else if m.duration > 0 {
-		m.countdown(root, state)
+		m.countdown(root, states)
 	} else {
 		panic("Must specify search episodes or duration")
 	}
@@ -108,7 +239,40 @@ func (m *MCTS) Simulate(state game.State, lineage []Segment) (map[game.Move]floa
 	return policy, metric
 }
 
-func (m *MCTS) iterate(root Node, state game.State) {
+// randomizable is implemented by states that can swap their source of randomness
+type randomizable interface {
+	UseRand(rng game.Rand) game.State
+}
+
+// randomize gives each goroutine its own source of randomness seeded from the
+// search's, together with a copy of the state drawing from it so that searching
+// neither consumes the random numbers of the actual game nor shares them
+// between goroutines
+func (m *MCTS) randomize(state game.State) []searchState {
+	states := make([]searchState, m.goroutines)
+	for i := range states {
+		rng := rand.New(rand.NewSource(m.rng.Int63()))
+		states[i] = searchState{state: state, rng: rng}
+		if s, ok := state.(randomizable); ok {
+			states[i].state = s.UseRand(rng)
+		}
+	}
+	return states
+}
+
+// searchState is the state a goroutine searches from and its source of randomness
+type searchState struct {
+	state   game.State
+	rng     *rand.Rand
+	playout game.Rollout // Reused by the goroutine's rollouts, nil till the first
+}
+
+// rollouter is implemented by states that can be played out in place
+type rollouter interface {
+	Rollout() game.Rollout
+}
+
+func (m *MCTS) iterate(root Node, states []searchState) {
 	task := make(chan any, m.episodes)
 	for i := 0; i < m.episodes; i++ {
 		task <- nil
@@ -118,37 +282,37 @@ func (m *MCTS) iterate(root Node, state game.State) {
 	var wg sync.WaitGroup
 	for i := 0; i < m.goroutines; i++ {
 		wg.Add(1)
-		go func() {
+		go func(s searchState) {
 			defer wg.Done()
 
 			for range task {
-				m.simulate(root, state)
+				m.simulate(root, &s)
 				m.metrics.AddEpisode()
 			}
-		}()
+		}(states[i])
 	}
 
 	wg.Wait()
 }
 
-func (m *MCTS) countdown(root Node, state game.State) {
+func (m *MCTS) countdown(root Node, states []searchState) {
 	done := make(chan any)
 	var wg sync.WaitGroup
 
 	for i := 0; i < m.goroutines; i++ {
 		wg.Add(1)
-		go func() {
+		go func(s searchState) {
 			defer wg.Done()
 			for {
 				select {
 				case <-done:
 					return
 				default:
-					m.simulate(root, state)
+					m.simulate(root, &s)
 					m.metrics.AddEpisode()
 				}
 			}
-		}()
+		}(states[i])
 	}
 
 	<-time.After(m.duration)
@@ -156,73 +320,95 @@ func (m *MCTS) countdown(root Node, state game.State) {
 	wg.Wait() // Wait for all goroutines to complete
 }
 
-// func (m *MCTS) findRoot(path []Segment, state game.State) {
-// 	root := traverse(m.root, path)
-// 	if root == nil {
-// 		m.root = newDecision(nil, state)
-// 		m.metrics.SetTreeReset(true)
-// 	} else {
-// 		root.parent = nil
-// 		m.root = root
-// 		m.metrics.SetTreeReset(false)
-// 	}
-// }
-
-// func traverse(root *decision, path []Segment) *decision {
-// 	if root == nil {
-// 		return nil
-// 	}
-//
-// 	node := root
-// 	for _, segment := range path {
-// 		child, ok := node.children[segment.Move]
-// 		if !ok { // Node has not expanded this move
-// 			return nil
-// 		}
-//
-// 		switch child := child.(type) {
-// 		case *decision:
-// 			if child.hash != segment.StateHash {
-// 				log.Warn().Msgf("node's state hash %d does not match segment's state hash %d", child.hash, segment.StateHash)
-// 				return nil
-// 			}
-// 			node = child
-// 		case *chance:
-// 			grandChild := child.selects(segment.StateHash)
-// 			if grandChild == nil {
-// 				return nil
-// 			}
-// 			node = grandChild
-// 		default:
-// 			panic("Unexpected node type")
-// 		}
-// 	}
-// 	return node
-// }
-
-func (m *MCTS) simulate(root Node, state game.State) {
-	newNode, newState := selectThenExpand(root, state)
-	player, score := rollout(newState, m.cutoff, m.evaluate, m.metrics)
+// findRoot detaches the subtree of the previous search reached by the lineage
+// of moves played since as the root of the next search, or starts a fresh tree
+// if the moves lead out of the tree or to another state
+func (m *MCTS) findRoot(state game.State, lineage []Segment) *decision {
+	root := traverse(m.root, lineage)
+	if root == nil || root.hash != state.Hash() {
+		m.metrics.SetTreeReset(true)
+		return newDecision(nil, state.Player(), state, m.selection)
+	}
+	root.parent = nil
+	m.metrics.SetTreeReset(false)
+	return root
+}
+
+// traverse descends a tree along a lineage of moves, through chance nodes by the
+// hash of the outcome, returning nil if a move or outcome was not explored
+func traverse(root *decision, lineage []Segment) *decision {
+	node := root
+	for _, segment := range lineage {
+		if node == nil {
+			return nil
+		}
+		index := slices.IndexFunc(node.explored, func(move game.Move) bool {
+			return sameMove(move, segment.Move)
+		})
+		if index < 0 { // Node has not expanded this move
+			return nil
+		}
+
+		switch child := node.children[index].(type) {
+		case *decision:
+			if child.hash != segment.StateHash {
+				log.Warn().Msgf("node's state hash %d does not match segment's state hash %d", child.hash, segment.StateHash)
+				return nil
+			}
+			node = child
+		case *chance:
+			node = child.selects(segment.StateHash)
+		default:
+			panic("Unexpected node type")
+		}
+	}
+	return node
+}
+
+// sameMove tells whether two moves are the same, comparing moves held by
+// pointer, such as the moves of other agents, by the values they point to
+func sameMove(a, b game.Move) bool {
+	if a == b {
+		return true
+	}
+	gameA, okA := a.(*game.GameMove)
+	gameB, okB := b.(*game.GameMove)
+	return okA && okB && *gameA == *gameB
+}
+
+func (m *MCTS) simulate(root Node, s *searchState) {
+	newNode, newState := selectThenExpand(root, s.state, s.rng)
+	var player string
+	var score float64
+	if r, ok := newState.(rollouter); ok && !m.copyRollouts {
+		if s.playout == nil {
+			s.playout = r.Rollout()
+		} else {
+			s.playout.Reset(newState)
+		}
+		player, score = rolloutInPlace(s.playout, s.rng, m.cutoff, m.evaluate, m.rolloutPolicy, m.metrics)
+	} else {
+		player, score = rollout(newState, s.rng, m.cutoff, m.evaluate, m.rolloutPolicy, m.metrics)
+	}
 	backup(newNode, player, score)
 }
 
-func selectThenExpand(root Node, state game.State) (Node, game.State) {
+func selectThenExpand(root Node, state game.State, rng *rand.Rand) (Node, game.State) {
 	parent := root
-	child, state, selected := parent.SelectOrExpand(state)
+	child, state, selected := parent.SelectOrExpand(state, rng)
 	for selected && (child != parent) {
 		parent = child
-		child, state, selected = parent.SelectOrExpand(state)
+		child, state, selected = parent.SelectOrExpand(state, rng)
 	}
 	return child, state
 }
 
-func rollout(state game.State, cutoff int, evaluate game.Evaluate, metrics metrics.Collector) (string, float64) {
+func rollout(state game.State, rng *rand.Rand, cutoff int, evaluate game.Evaluate, policy RolloutPolicy, metrics metrics.Collector) (string, float64) {
 	depth := 0
 	moves := state.LegalMoves()
-	rng := newRNG()
 	// Rollout till game over or for cutoff number of moves
 	for len(moves) > 0 && (depth < cutoff) {
-		move := moves[rng.Intn(len(moves))] // Random rollout policy
+		move := moves[policy.Choose(state, moves, rng)]
 		state = state.Play(move)
 		moves = state.LegalMoves()
 		depth++
@@ -237,6 +423,27 @@ func rollout(state game.State, cutoff int, evaluate game.Evaluate, metrics metri
 	return state.Player(), evaluate(state)
 }
 
+// rolloutInPlace rolls out like rollout, but plays the moves in place so that
+// the states of the tree are left untouched without copying them on every move
+func rolloutInPlace(r game.Rollout, rng *rand.Rand, cutoff int, evaluate game.Evaluate, policy RolloutPolicy, metrics metrics.Collector) (string, float64) {
+	depth := 0
+	moves := r.LegalMoves()
+	// Rollout till game over or for cutoff number of moves
+	for len(moves) > 0 && (depth < cutoff) {
+		r.Apply(moves[policy.Choose(r.State(), moves, rng)])
+		moves = r.LegalMoves()
+		depth++
+	}
+
+	if len(moves) == 0 { // Game over before cutoff
+		metrics.AddFullPlayout()
+		return r.Winner(), Win
+	}
+
+	// At cutoff state, return an evaluation score from current player's perspective
+	return r.Player(), evaluate(r.State())
+}
+
 func backup(newNode Node, player string, score float64) {
 	node := newNode
 	for node != nil {
@@ -2,7 +2,10 @@ package searcher
 
 import (
 	"fmt"
+	"math/rand"
+	"risk/experiments/metrics"
 	"risk/game"
+	"slices"
 	"testing"
 
 	"github.com/rs/zerolog/log"
@@ -91,7 +94,7 @@ func TestSimulate(t *testing.T) {
 			rewards:  Win, // Backup a win for P1
 			visits:   1,
 			explored: []game.Move{move2}, // Expand with M2 to C2
-			children: []Node{&decision{player: "player2", rewards: Loss, visits: 1}},
+			children: []Node{&decision{player: "player2", rewards: Win, visits: 1}},
 		}
 		require.Contains(t, []map[game.Move]float64{
 			{move1: 1}, // If C1 selected
@@ -113,7 +116,7 @@ func TestSimulate(t *testing.T) {
 			explored: []game.Move{move1, move2}, // Expand both M1 and M2
 			children: []Node{
 				&decision{player: "player1", rewards: Win, visits: 1},
-				&decision{player: "player2", rewards: Loss, visits: 1},
+				&decision{player: "player2", rewards: Win, visits: 1},
 			},
 		}
 		require.Equal(t, map[game.Move]float64{move1: 1, move2: 1}, got, "Should explore M1 and M2 each once")
@@ -128,7 +131,7 @@ func TestSimulate(t *testing.T) {
 		// After 2 episodes, both moves have been tried once
 		// 3rd episode selects either child since they have equal UCT scores:
 		// C1: rewards=WIN, visits=1, parent_visits=2, score = 1 + sqrt(2ln(2))
-		// C2: rewards=LOSS, visits=1, parent_visits=2, score = 1 + sqrt(2ln(2))
+		// C2: rewards=WIN, visits=1, parent_visits=2, score = 1 + sqrt(2ln(2))
 		// and expands it with a random move
 		expectedRoot11 := &decision{
 			player:   "player1",
@@ -145,7 +148,7 @@ func TestSimulate(t *testing.T) {
 						&decision{player: "player1", rewards: Win, visits: 1},
 					},
 				},
-				&decision{player: "player2", rewards: Loss, visits: 1},
+				&decision{player: "player2", rewards: Win, visits: 1},
 			},
 		}
 		expectedRoot12 := &decision{
@@ -160,10 +163,10 @@ func TestSimulate(t *testing.T) {
 					visits:   2,
 					explored: []game.Move{move2}, // Expand C1 with M2
 					children: []Node{
-						&decision{player: "player2", rewards: Loss, visits: 1},
+						&decision{player: "player2", rewards: Win, visits: 1},
 					},
 				},
-				&decision{player: "player2", rewards: Loss, visits: 1},
+				&decision{player: "player2", rewards: Win, visits: 1},
 			},
 		}
 		expectedRoot21 := &decision{
@@ -172,13 +175,14 @@ func TestSimulate(t *testing.T) {
 			visits:   3,
 			explored: []game.Move{move1, move2}, // Select C2
 			children: []Node{
+				&decision{player: "player1", rewards: Win, visits: 1},
 				&decision{ // Select C2
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move1}, // Expand C2 with M1
 					children: []Node{
-						&decision{player: "player1", rewards: Win, visits: 1},
+						&decision{player: "player1", rewards: Loss, visits: 1},
 					},
 				},
 			},
@@ -189,9 +193,10 @@ func TestSimulate(t *testing.T) {
 			visits:   3,
 			explored: []game.Move{move1, move2}, // Select C2
 			children: []Node{
+				&decision{player: "player1", rewards: Win, visits: 1},
 				&decision{ // Select C2
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move2}, // Expand C2 with M2
 					children: []Node{
@@ -215,10 +220,10 @@ func TestSimulate(t *testing.T) {
 
 		// 4th episode selects the child not selected by E3 since it has equal exploitation but bigger exploration
 		// C1: rewards=WIN*2, visits=2, parent_visits=3, score = 2/2 + sqrt(2ln(3)/2)
-		// C2: rewards=LOSS, visits=1, parent_visits=3, score = 1/1 + sqrt(2ln(3))
+		// C2: rewards=WIN, visits=1, parent_visits=3, score = 1/1 + sqrt(2ln(3))
 		// or
 		// C1: rewards=WIN, visits=1, parent_visits=3, score = 1/1 + sqrt(2ln(3))
-		// C2: rewards=LOSS*2, visits=2, parent_visits=3, score = 2/2 + sqrt(2ln(3)/2)
+		// C2: rewards=WIN*2, visits=2, parent_visits=3, score = 2/2 + sqrt(2ln(3)/2)
 		expectedRoot11 := &decision{
 			player:   "player1",
 			rewards:  Win * 4, // Backup 4 wins for P1
@@ -236,11 +241,11 @@ func TestSimulate(t *testing.T) {
 				},
 				&decision{
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move1}, // Expand C1 with M1
 					children: []Node{
-						&decision{player: "player1", rewards: Win, visits: 1},
+						&decision{player: "player1", rewards: Loss, visits: 1},
 					},
 				},
 			},
@@ -262,7 +267,7 @@ func TestSimulate(t *testing.T) {
 				},
 				&decision{
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move2},
 					children: []Node{
@@ -283,16 +288,16 @@ func TestSimulate(t *testing.T) {
 					visits:   2,
 					explored: []game.Move{move2},
 					children: []Node{
-						&decision{player: "player2", rewards: Loss, visits: 1},
+						&decision{player: "player2", rewards: Win, visits: 1},
 					},
 				},
 				&decision{
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move1},
 					children: []Node{
-						&decision{player: "player1", rewards: Win, visits: 1},
+						&decision{player: "player1", rewards: Loss, visits: 1},
 					},
 				},
 			},
@@ -309,12 +314,12 @@ func TestSimulate(t *testing.T) {
 					visits:   2,
 					explored: []game.Move{move2},
 					children: []Node{
-						&decision{player: "player2", rewards: Loss, visits: 1},
+						&decision{player: "player2", rewards: Win, visits: 1},
 					},
 				},
 				&decision{
 					player:   "player2",
-					rewards:  Loss * 2,
+					rewards:  Win * 2,
 					visits:   2,
 					explored: []game.Move{move2},
 					children: []Node{
@@ -358,11 +363,11 @@ func TestSimulateParallel(t *testing.T) {
 			},
 			&decision{
 				player:   "player2",
-				rewards:  Loss * 2,
+				rewards:  Win * 2,
 				visits:   2,
 				explored: []game.Move{move1},
 				children: []Node{
-					&decision{player: "player1", rewards: Win, visits: 1},
+					&decision{player: "player1", rewards: Loss, visits: 1},
 				},
 			},
 		},
@@ -384,7 +389,7 @@ func TestSimulateParallel(t *testing.T) {
 			},
 			&decision{
 				player:   "player2",
-				rewards:  Loss * 2,
+				rewards:  Win * 2,
 				visits:   2,
 				explored: []game.Move{move2},
 				children: []Node{
@@ -405,16 +410,16 @@ func TestSimulateParallel(t *testing.T) {
 				visits:   2,
 				explored: []game.Move{move2},
 				children: []Node{
-					&decision{player: "player2", rewards: Loss, visits: 1},
+					&decision{player: "player2", rewards: Win, visits: 1},
 				},
 			},
 			&decision{
 				player:   "player2",
-				rewards:  Loss * 2,
+				rewards:  Win * 2,
 				visits:   2,
 				explored: []game.Move{move1},
 				children: []Node{
-					&decision{player: "player1", rewards: Win, visits: 1},
+					&decision{player: "player1", rewards: Loss, visits: 1},
 				},
 			},
 		},
@@ -431,12 +436,12 @@ func TestSimulateParallel(t *testing.T) {
 				visits:   2,
 				explored: []game.Move{move2},
 				children: []Node{
-					&decision{player: "player2", rewards: Loss, visits: 1},
+					&decision{player: "player2", rewards: Win, visits: 1},
 				},
 			},
 			&decision{
 				player:   "player2",
-				rewards:  Loss * 2,
+				rewards:  Win * 2,
 				visits:   2,
 				explored: []game.Move{move2},
 				children: []Node{
@@ -729,7 +734,7 @@ func TestSimulateStochastic(t *testing.T) {
 			visits:   1,
 			explored: []game.Move{move2},
 			children: []Node{ // Expand M2 to S2
-				&decision{player: "player2", rewards: Win, visits: 1}, // No outcomes yet
+				&decision{player: "player2", rewards: Loss, visits: 1}, // No outcomes yet
 			},
 		}
 
@@ -762,7 +767,7 @@ func TestSimulateStochastic(t *testing.T) {
 				},
 				&decision{ // S2
 					player:  "player2",
-					rewards: Win,
+					rewards: Loss,
 					visits:  1,
 				},
 			},
@@ -799,14 +804,14 @@ func TestSimulateStochastic(t *testing.T) {
 					children: []*decision{
 						{ // Outcome S3
 							player:  "player2",
-							rewards: Win,
+							rewards: Loss,
 							visits:  1,
 						},
 					},
 				},
 				&decision{ // S2
 					player:  "player2",
-					rewards: Win,
+					rewards: Loss,
 					visits:  1,
 				},
 			},
@@ -842,7 +847,7 @@ func TestSimulateStochastic(t *testing.T) {
 					children: []*decision{
 						{ // Outcome S3
 							player:  "player2",
-							rewards: Win,
+							rewards: Loss,
 							visits:  1,
 						},
 						{ // Outcome S1
@@ -854,7 +859,7 @@ func TestSimulateStochastic(t *testing.T) {
 				},
 				&decision{ // S2
 					player:  "player2",
-					rewards: Win,
+					rewards: Loss,
 					visits:  1,
 				},
 			},
@@ -890,7 +895,7 @@ func TestSimulateStochastic(t *testing.T) {
 					children: []*decision{
 						{ // Outcome S3
 							player:   "player2",
-							rewards:  Win * 2,
+							rewards:  Loss * 2,
 							visits:   2,
 							explored: []game.Move{move3},
 							children: []Node{
@@ -910,7 +915,7 @@ func TestSimulateStochastic(t *testing.T) {
 				},
 				&decision{ // S2
 					player:  "player2",
-					rewards: Win,
+					rewards: Loss,
 					visits:  1,
 				},
 			},
@@ -956,7 +961,7 @@ func TestSimulateStochasticParallel(t *testing.T) {
 				children: []*decision{
 					{ // Outcome S3
 						player:   "player2",
-						rewards:  Win * 2,
+						rewards:  Loss * 2,
 						visits:   2,
 						explored: []game.Move{move3},
 						children: []Node{
@@ -976,7 +981,7 @@ func TestSimulateStochasticParallel(t *testing.T) {
 			},
 			&decision{ // S2
 				player:  "player2",
-				rewards: Win,
+				rewards: Loss,
 				visits:  1,
 			},
 		},
@@ -989,6 +994,189 @@ func TestSimulateStochasticParallel(t *testing.T) {
 	require.True(t, containsTree([]*decision{expectedRoot}, mcts.root), "Tree should be constructed correctly")
 }
 
+func TestRolloutInPlace(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+
+	t.Run("rolls out like copying states", func(t *testing.T) {
+		for _, cutoff := range []int{10, MaxCutoff} {
+			copied := state.UseRand(rand.New(rand.NewSource(2)))
+			inPlace := state.UseRand(rand.New(rand.NewSource(2))).(rollouter).Rollout()
+
+			expectedPlayer, expectedScore := rollout(copied, rand.New(rand.NewSource(3)), cutoff, game.EvaluateResources, UniformRollout{}, metrics.NewDummyCollector())
+			player, score := rolloutInPlace(inPlace, rand.New(rand.NewSource(3)), cutoff, game.EvaluateResources, UniformRollout{}, metrics.NewDummyCollector())
+
+			require.Equal(t, expectedPlayer, player, "Should reach the same state with cutoff %d", cutoff)
+			require.Equal(t, expectedScore, score, "Should reach the same state with cutoff %d", cutoff)
+		}
+	})
+
+	t.Run("grows the same tree as copying states", func(t *testing.T) {
+		copied := NewMCTS(1, WithEpisodes(100), WithSeed(4))
+		copied.copyRollouts = true
+		inPlace := NewMCTS(1, WithEpisodes(100), WithSeed(4))
+
+		copied.Simulate(state, nil)
+		inPlace.Simulate(state, nil)
+
+		require.Equal(t, copied.root.rewards, inPlace.root.rewards)
+		require.Equal(t, copied.root.explored, inPlace.root.explored)
+	})
+}
+
+func TestSimulateDefenderDice(t *testing.T) {
+	rules := game.NewStandardRules()
+	rules.DefenderDice = true
+	state := game.NewGameState(game.CreateMap(), rules, game.WithPlayers(3), game.WithSeed(1))
+	rng := rand.New(rand.NewSource(1))
+	for state.Phase != game.DefendPhase { // Play till an attack awaits the defender's dice
+		moves := state.LegalMoves()
+		state = state.Play(moves[rng.Intn(len(moves))]).(*game.GameState)
+	}
+	defender := state.Player()
+	require.NotEqual(t, state.CurrentPlayer, state.Decider(), "Should leave the dice to the defender")
+
+	mcts := NewMCTS(1, WithEpisodes(200), WithSeed(1))
+	got, _ := mcts.Simulate(state, nil)
+
+	require.Equal(t, defender, mcts.root.player)
+	for move := range got {
+		require.Equal(t, game.DefendAction, move.(*game.GameMove).ActionType, "Should search the defender's dice")
+	}
+	for _, child := range mcts.root.children {
+		outcome := child.(*chance)
+		require.Equal(t, defender, outcome.player, "Should back up rewards from the defender's perspective")
+		for _, grandChild := range outcome.children {
+			require.Equal(t, defender, grandChild.mover, "Should back up rewards from the defender's perspective")
+		}
+	}
+}
+
+func TestTreeReuse(t *testing.T) {
+	move1 := mockMove{id: 1, stochastic: true}
+	move2 := mockMove{id: 2}
+	newState := func() mockStateStochastic {
+		return mockStateStochastic{player: "player1", state: "S0", nextOutcome: alternator()}
+	}
+	s1 := mockStateStochastic{player: "player1", state: "S1", depth: 1}
+	s2 := mockStateStochastic{player: "player2", state: "S2", depth: 1}
+
+	t.Run("reuses the subtree reached by a deterministic move", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(5), WithMetrics())
+		mcts.Simulate(newState(), nil)
+		expected := mcts.root.children[slices.Index(mcts.root.explored, game.Move(move2))].(*decision)
+
+		_, metric := mcts.Simulate(s2, []Segment{{Move: move2, StateHash: s2.Hash()}})
+
+		require.False(t, metric.IsTreeReset, "Should not reset the tree")
+		require.Same(t, expected, mcts.root, "Should search from the subtree of the move played")
+		require.Nil(t, mcts.root.parent, "Should detach the subtree")
+		require.Equal(t, float64(6), mcts.root.visits, "Should add to the visits of the subtree")
+	})
+
+	t.Run("reuses the subtree of the outcome of a stochastic move", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(5), WithMetrics())
+		mcts.Simulate(newState(), nil)
+		outcomes := mcts.root.children[slices.Index(mcts.root.explored, game.Move(move1))].(*chance)
+		expected := outcomes.selects(s1.Hash())
+		require.NotNil(t, expected)
+
+		_, metric := mcts.Simulate(s1, []Segment{{Move: move1, StateHash: s1.Hash()}})
+
+		require.False(t, metric.IsTreeReset, "Should not reset the tree")
+		require.Same(t, expected, mcts.root, "Should search from the subtree of the outcome")
+	})
+
+	t.Run("reuses the tree when searching the same state again", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(5), WithMetrics())
+		state := newState()
+		mcts.Simulate(state, nil)
+		expected := mcts.root
+
+		_, metric := mcts.Simulate(state, nil)
+
+		require.False(t, metric.IsTreeReset)
+		require.Same(t, expected, mcts.root)
+		require.Equal(t, float64(10), mcts.root.visits)
+	})
+
+	t.Run("resets the tree when the lineage leaves it", func(t *testing.T) {
+		for name, lineage := range map[string][]Segment{
+			"unexplored move":    {{Move: mockMove{id: 4}, StateHash: s2.Hash()}},
+			"unexplored outcome": {{Move: move1, StateHash: game.StateHash('9')}},
+			"other state":        {{Move: move2, StateHash: s1.Hash()}},
+		} {
+			mcts := NewMCTS(1, WithEpisodes(5), WithMetrics())
+			mcts.Simulate(newState(), nil)
+			previous := mcts.root
+
+			_, metric := mcts.Simulate(s2, lineage)
+
+			require.True(t, metric.IsTreeReset, "Should reset the tree for an %s", name)
+			require.NotSame(t, previous, mcts.root)
+			require.Equal(t, float64(5), mcts.root.visits, "Should search from a fresh tree for an %s", name)
+		}
+	})
+
+	t.Run("matches moves played by other agents by value", func(t *testing.T) {
+		state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+		mcts := NewMCTS(1, WithEpisodes(50), WithSeed(1), WithMetrics())
+		mcts.Simulate(state, nil)
+		played := *mcts.root.explored[0].(*game.GameMove) // Same move held by another pointer
+		next := state.Play(&played)
+		expected := mcts.root.children[0]
+
+		_, metric := mcts.Simulate(next, []Segment{{Move: &played, StateHash: next.Hash()}})
+
+		require.False(t, metric.IsTreeReset, "Should recognize the move")
+		require.Same(t, expected, mcts.root)
+	})
+}
+
+func TestClone(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	mcts := NewMCTS(1, WithEpisodes(10), WithSeed(1))
+	mcts.Simulate(state, nil)
+
+	clone := mcts.Clone(WithTreePolicy(PUCT{CPuct: CPuct}), WithRootNoise(0, NoiseWeight))
+
+	require.Nil(t, clone.root, "Should start from a fresh tree")
+	require.Equal(t, PUCT{CPuct: CPuct}, clone.TreePolicy())
+	require.True(t, clone.ReadsPriors())
+	require.False(t, mcts.ReadsPriors())
+	require.True(t, clone.HasRootNoise())
+	require.Equal(t, UCT{CSquared: CSquared}, mcts.TreePolicy(), "Should leave the original search unchanged")
+	require.False(t, mcts.HasRootNoise(), "Should leave the original search unchanged")
+	require.Nil(t, mcts.root.selection.prior, "Should leave the tree of the original search unchanged")
+
+	clone.Simulate(state, nil)
+	require.NotSame(t, mcts.rng, clone.rng, "Should draw from its own source of randomness")
+}
+
+// parallelism lists the goroutines tested by experiments.RunParallelismExperiment
+var parallelism = []int{1, 4, 8, 16, 32, 64}
+
+func BenchmarkSimulate(b *testing.B) {
+	const episodes = 256
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithSeed(1))
+
+	for _, mode := range []struct {
+		name         string
+		copyRollouts bool
+	}{{"copy", true}, {"in-place", false}} {
+		for _, goroutines := range parallelism {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", mode.name, goroutines), func(b *testing.B) {
+				m := NewMCTS(goroutines, WithEpisodes(episodes), WithSeed(1))
+				m.copyRollouts = mode.copyRollouts
+				for i := 0; i < b.N; i++ {
+					m.root = nil // Search afresh rather than grow the previous tree
+					m.Simulate(state, nil)
+				}
+				b.ReportMetric(float64(b.N*episodes)/b.Elapsed().Seconds(), "episodes/s")
+			})
+		}
+	}
+}
+
 func containsTree(expected []*decision, actual *decision) bool {
 	for _, candidate := range expected {
 		if decisionEqual(candidate, actual) {
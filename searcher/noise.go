@@ -0,0 +1,80 @@
+package searcher
+
+import (
+	"math"
+	"math/rand"
+	"risk/game"
+	"slices"
+)
+
+const DirichletScale = 10.0 // Concentration of root noise times the number of legal moves, when scaled
+
+const NoiseWeight = 0.25 // Weight of root noise in the priors of the root
+
+// rootNoise mixes Dirichlet noise into the priors of the root of a search
+type rootNoise struct {
+	alpha   float64 // Concentration, scaled to the number of legal moves if not positive
+	epsilon float64 // Weight of the noise
+}
+
+// apply recomputes the priors of the root with fresh noise, so that noise does
+// not build up on a root reused from the previous search, and reorders the
+// unexplored moves by their noisy priors
+func (n rootNoise) apply(root *decision, state game.State, prior Prior, rng *rand.Rand) {
+	moves := append(slices.Clone(root.explored), root.unexplored...)
+	if len(moves) < 2 {
+		return
+	}
+	alpha := n.alpha
+	if alpha <= 0 {
+		alpha = DirichletScale / float64(len(moves))
+	}
+
+	priors := slices.Clone(prior.Probabilities(state, moves))
+	for i, noise := range dirichlet(rng, alpha, len(moves)) {
+		priors[i] = (1-n.epsilon)*priors[i] + n.epsilon*noise
+	}
+	explored := len(root.explored)
+	root.priors = append(priors[:explored], sortByPrior(root.unexplored, priors[explored:])...)
+}
+
+// dirichlet samples n probabilities from a symmetric Dirichlet distribution of
+// concentration alpha by normalizing Gamma(alpha, 1) samples
+func dirichlet(rng *rand.Rand, alpha float64, n int) []float64 {
+	samples := make([]float64, n)
+	total := 0.0
+	for i := range samples {
+		samples[i] = sampleGamma(rng, alpha)
+		total += samples[i]
+	}
+	if total == 0 { // Every sample underflowed with a tiny alpha
+		samples[rng.Intn(n)] = 1
+		return samples
+	}
+	for i := range samples {
+		samples[i] /= total
+	}
+	return samples
+}
+
+// sampleGamma samples a Gamma(alpha, 1) distribution by the method of Marsaglia
+// and Tsang, boosting alpha below 1 by a uniform power
+func sampleGamma(rng *rand.Rand, alpha float64) float64 {
+	if alpha < 1 {
+		return sampleGamma(rng, alpha+1) * math.Pow(rng.Float64(), 1/alpha)
+	}
+	d := alpha - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < x*x/2+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
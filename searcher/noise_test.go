@@ -0,0 +1,132 @@
+package searcher
+
+import (
+	"math/rand"
+	"risk/game"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirichlet(t *testing.T) {
+	t.Run("samples probabilities", func(t *testing.T) {
+		for _, alpha := range []float64{0.03, 0.3, 3} {
+			got := dirichlet(rand.New(rand.NewSource(1)), alpha, 10)
+
+			require.Len(t, got, 10)
+			total := 0.0
+			for _, probability := range got {
+				require.GreaterOrEqual(t, probability, 0.0)
+				total += probability
+			}
+			require.InDelta(t, 1.0, total, 1e-9, "Should sum to 1 with alpha %v", alpha)
+		}
+	})
+
+	t.Run("samples the same noise with the same seed", func(t *testing.T) {
+		require.Equal(t, dirichlet(rand.New(rand.NewSource(1)), 0.3, 10), dirichlet(rand.New(rand.NewSource(1)), 0.3, 10))
+	})
+
+	t.Run("samples gamma distributions of mean alpha", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		for _, alpha := range []float64{0.3, 1, 2.5} {
+			total := 0.0
+			for i := 0; i < 10000; i++ {
+				total += sampleGamma(rng, alpha)
+			}
+			require.InDelta(t, alpha, total/10000, 0.05*alpha+0.02, "Should average alpha %v", alpha)
+		}
+	})
+}
+
+func TestRootNoise(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	numMoves := float64(len(state.LegalMoves()))
+
+	t.Run("is off by default", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(10), WithSeed(1))
+
+		mcts.Simulate(state, nil)
+
+		require.False(t, mcts.HasRootNoise())
+		require.Nil(t, mcts.root.priors, "Should not compute priors")
+	})
+
+	t.Run("mixes noise into the priors of the root", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(10), WithSeed(1), WithTreePolicy(PUCT{CPuct: CPuct}), WithRootNoise(0, NoiseWeight))
+
+		mcts.Simulate(state, nil)
+
+		require.True(t, mcts.HasRootNoise())
+		require.Len(t, mcts.root.priors, int(numMoves))
+		total := 0.0
+		for _, prior := range mcts.root.priors {
+			require.GreaterOrEqual(t, prior, (1-NoiseWeight)/numMoves, "Should keep the weight of the uniform prior")
+			total += prior
+		}
+		require.InDelta(t, 1.0, total, 1e-9)
+		require.NotEqual(t, UniformPrior{}.Probabilities(state, mcts.root.explored), mcts.root.priors, "Should perturb the prior")
+		require.IsNonIncreasing(t, mcts.root.priors[len(mcts.root.explored):], "Should expand moves by their noisy priors")
+		for _, child := range mcts.root.children {
+			child := child.(*decision)
+			moves := append(append([]game.Move(nil), child.explored...), child.unexplored...)
+			require.Equal(t, UniformPrior{}.Probabilities(nil, moves), child.priors, "Should not add noise below the root")
+		}
+	})
+
+	t.Run("reproduces the noise with the same seed", func(t *testing.T) {
+		search := func() *MCTS {
+			mcts := NewMCTS(1, WithEpisodes(10), WithSeed(1), WithRootNoise(0.3, NoiseWeight))
+			mcts.Simulate(state, nil)
+			return mcts
+		}
+
+		first, second := search(), search()
+
+		require.Equal(t, first.root.priors, second.root.priors)
+		require.Equal(t, first.root.explored, second.root.explored)
+	})
+
+	t.Run("renews the noise of a reused root", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(10), WithSeed(1), WithRootNoise(0.3, NoiseWeight))
+		mcts.Simulate(state, nil)
+		previous := append([]float64(nil), mcts.root.priors...)
+
+		_, metric := mcts.Simulate(state, nil)
+
+		require.False(t, metric.IsTreeReset)
+		require.NotEqual(t, previous, mcts.root.priors, "Should sample fresh noise")
+		for _, prior := range mcts.root.priors {
+			require.GreaterOrEqual(t, prior, (1-NoiseWeight)/numMoves, "Should not build up noise")
+		}
+	})
+
+	t.Run("changes how visits are distributed under PUCT", func(t *testing.T) {
+		search := func(options ...Option) map[game.Move]float64 {
+			mcts := NewMCTS(1, append([]Option{WithEpisodes(200), WithCutoff(10), WithSeed(1), WithTreePolicy(PUCT{CPuct: CPuct})}, options...)...)
+			visits, _ := mcts.Simulate(state, nil)
+			return visits
+		}
+		byMove := func(visits map[game.Move]float64) map[game.GameMove]float64 {
+			got := map[game.GameMove]float64{}
+			for move, n := range visits {
+				got[*move.(*game.GameMove)] = n
+			}
+			return got
+		}
+
+		plain := byMove(search())
+		noisy := byMove(search(WithRootNoise(0.3, 0.5)))
+
+		require.Equal(t, byMove(search()), plain, "Should search the same way without noise")
+		require.NotEqual(t, plain, noisy, "Should visit the moves the noise favors more")
+	})
+
+	t.Run("turns off without weight", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(10), WithRootNoise(0.3, NoiseWeight))
+
+		mcts.Configure(WithRootNoise(0.3, 0))
+
+		require.False(t, mcts.HasRootNoise())
+	})
+}
@@ -3,6 +3,7 @@ package searcher
 import (
 	"math"
 	"math/rand"
+	"risk/game"
 	"time"
 )
 
@@ -10,6 +11,8 @@ import (
 
 const CSquared = 2.0 // Exploration constant
 
+const CPuct = 1.5 // Exploration constant of PUCT
+
 const MaxCutoff = 10000 // Maximum rollout depth in moves
 
 const Win = 1.0   // Reward for winning outcome
@@ -34,8 +37,145 @@ func (u uct) evaluate(rewards float64, childVisits float64) float64 {
 	return rewards/childVisits + math.Sqrt(u.numerator/childVisits)
 }
 
-func computeReward(player string, score float64, current string) float64 {
-	if player == current {
+const BiasWeight = 1.0 // Weight of the heuristic term of progressive bias
+
+// SelectionPolicy is the formula with which a fully expanded node selects a
+// child, among the tree policies with an exploration constant alone
+type SelectionPolicy int
+
+const (
+	UCTSelection  SelectionPolicy = iota // Upper confidence bound applied to trees
+	PUCTSelection                        // Predictor UCT, exploring moves in proportion to their prior as in AlphaZero
+)
+
+// ChildStats are the statistics a tree policy values a child by, kept from the
+// perspective of the player choosing among the children
+type ChildStats struct {
+	Rewards float64
+	Squares float64 // Sum of the squared rewards
+	Visits  float64
+	Prior   float64 // Prior of the move to the child, 0 without a prior
+	Bias    float64 // Heuristic score of the move to the child, 0 unless the policy is Biased
+}
+
+// TreePolicy values the children of a fully expanded node, which selects the
+// child of highest value
+type TreePolicy interface {
+	Value(parentVisits float64, child ChildStats) float64
+}
+
+// Biased is implemented by tree policies biasing selection by a heuristic
+// evaluation of the state each move leads to, scored once the move is expanded
+type Biased interface {
+	TreePolicy
+	Heuristic() game.Evaluate
+}
+
+// UCT is the upper confidence bound applied to trees, weighing exploration by
+// the square of the exploration constant
+type UCT struct {
+	CSquared float64
+}
+
+func (u UCT) Value(parentVisits float64, child ChildStats) float64 {
+	return newUCT(u.CSquared, parentVisits).evaluate(child.Rewards, child.Visits)
+}
+
+// PUCT is the predictor UCT of AlphaZero, exploring moves in proportion to
+// their prior
+type PUCT struct {
+	CPuct float64
+}
+
+func (p PUCT) Value(parentVisits float64, child ChildStats) float64 {
+	return newPUCT(p.CPuct, parentVisits).evaluate(child.Rewards, child.Visits, child.Prior)
+}
+
+// UCB1Tuned bounds the exploration of each child by the variance of its rewards
+// rather than by the largest variance rewards may have, exploring children of
+// steady outcomes less
+type UCB1Tuned struct{}
+
+func (UCB1Tuned) Value(parentVisits float64, child ChildStats) float64 {
+	if parentVisits == 0 || child.Visits == 0 {
+		panic("visits cannot be 0")
+	}
+	// Rewards between -1 and 1 are halved into rewards between 0 and 1 of mean
+	// (q/n+1)/2 and variance V/4, then doubled back:
+	// UCB1-Tuned = q/n + 2*sqrt(ln(N)/n * min(1/4, V/4 + sqrt(2*ln(N)/n)))
+	mean := child.Rewards / child.Visits
+	variance := max(child.Squares/child.Visits-mean*mean, 0)
+	logRatio := math.Log(parentVisits) / child.Visits
+	return mean + 2*math.Sqrt(logRatio*min(0.25, variance/4+math.Sqrt(2*logRatio)))
+}
+
+// ProgressiveBias adds to UCT a heuristic score of the state each move leads
+// to, whose weight decays as the child is visited and its rewards take over
+type ProgressiveBias struct {
+	UCT
+	Weight   float64
+	Evaluate game.Evaluate
+}
+
+// NewProgressiveBias returns progressive bias by an evaluation function,
+// defaulting to the resources of players
+func NewProgressiveBias(evaluate game.Evaluate) *ProgressiveBias {
+	if evaluate == nil {
+		evaluate = game.EvaluateResources
+	}
+	return &ProgressiveBias{UCT: UCT{CSquared: CSquared}, Weight: BiasWeight, Evaluate: evaluate}
+}
+
+func (p *ProgressiveBias) Value(parentVisits float64, child ChildStats) float64 {
+	// Progressive bias = UCT + w*H/(n+1)
+	return p.UCT.Value(parentVisits, child) + p.Weight*child.Bias/(child.Visits+1)
+}
+
+func (p *ProgressiveBias) Heuristic() game.Evaluate {
+	return p.Evaluate
+}
+
+// selection configures how the nodes of a tree select and expand moves. Nodes
+// without one select by UCT and expand moves at random.
+type selection struct {
+	policy TreePolicy
+	prior  Prior // Orders the expansion of moves if set
+}
+
+// treePolicy returns the tree policy selecting the children of nodes
+func (s *selection) treePolicy() TreePolicy {
+	if s == nil || s.policy == nil {
+		return UCT{CSquared: CSquared}
+	}
+	return s.policy
+}
+
+type puct struct {
+	numerator float64
+}
+
+func newPUCT(cPuct float64, parentVisits float64) *puct {
+	if parentVisits == 0 {
+		panic("parent visits cannot be 0")
+	}
+	return &puct{numerator: cPuct * math.Sqrt(parentVisits)}
+}
+
+func (p puct) evaluate(rewards float64, childVisits float64, prior float64) float64 {
+	if childVisits == 0 {
+		panic("child visits cannot be 0")
+	}
+	// PUCT = q/n + c*P*sqrt(N)/(1+n)
+	return rewards/childVisits + prior*p.numerator/(1+childVisits)
+}
+
+// computeReward returns the reward of a score obtained by a player from the
+// perspective of the given player, opponents' scores counting against it. The
+// perspective is that of the player who moved into a node rather than the one to
+// move there, as the two differ whenever play passes to another player, be it
+// at the end of a turn or for a defender to choose its dice.
+func computeReward(player string, score float64, perspective string) float64 {
+	if player == perspective {
 		return score
 	}
 	return -score
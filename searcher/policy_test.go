@@ -2,6 +2,7 @@ package searcher
 
 import (
 	"math"
+	"risk/game"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -70,3 +71,124 @@ func TestUCTEvaluate(t *testing.T) {
 			"More rewards should increase exploitation term")
 	})
 }
+
+func TestPUCTEvaluate(t *testing.T) {
+	t.Run("computing PUCT value", func(t *testing.T) {
+		policy := newPUCT(1.5, 100)
+		got := policy.evaluate(5.0, 10, 0.4)
+
+		expected := 5.0/10 + 1.5*0.4*math.Sqrt(100)/(1+10)
+		require.InDelta(t, expected, got, 0.0001,
+			"Should compute q/n + c*P*sqrt(N)/(1+n)")
+	})
+
+	t.Run("panics with zero visits", func(t *testing.T) {
+		require.Panics(t, func() {
+			newPUCT(1.5, 0)
+		}, "Should panic when N is 0")
+		require.Panics(t, func() {
+			newPUCT(1.5, 100).evaluate(5.0, 0, 0.4)
+		}, "Should panic when n is 0")
+	})
+
+	t.Run("exploration term increases with prior", func(t *testing.T) {
+		policy := newPUCT(1.5, 100)
+
+		require.Greater(t, policy.evaluate(5.0, 10, 0.8), policy.evaluate(5.0, 10, 0.2),
+			"More probable moves should be explored more")
+	})
+
+	t.Run("exploration term decreases with child visits", func(t *testing.T) {
+		policy := newPUCT(1.5, 100)
+
+		require.Greater(t, policy.evaluate(5.0, 10, 0.4), policy.evaluate(10.0, 20, 0.4),
+			"More child visits should decrease exploration term")
+	})
+}
+
+func TestTreePolicies(t *testing.T) {
+	steady := ChildStats{Rewards: 5, Squares: 10 * 0.25, Visits: 10} // Rewards of 0.5 every time
+	volatile := ChildStats{Rewards: 5, Squares: 10, Visits: 10}      // Rewards of 1 or -1
+
+	t.Run("UCT explores more with a larger constant", func(t *testing.T) {
+		require.Equal(t, newUCT(CSquared, 100).evaluate(5, 10), UCT{CSquared: CSquared}.Value(100, steady))
+		require.Greater(t, UCT{CSquared: 4}.Value(100, steady), UCT{CSquared: 1}.Value(100, steady))
+	})
+
+	t.Run("UCB1-Tuned explores children of volatile rewards more", func(t *testing.T) {
+		steady := ChildStats{Rewards: 250, Squares: 500 * 0.25, Visits: 500}
+		volatile := ChildStats{Rewards: 250, Squares: 500, Visits: 500}
+
+		require.Greater(t, UCB1Tuned{}.Value(1000, volatile), UCB1Tuned{}.Value(1000, steady))
+		require.Equal(t, UCT{CSquared: CSquared}.Value(1000, volatile), UCT{CSquared: CSquared}.Value(1000, steady),
+			"UCT should not tell the children apart")
+	})
+
+	t.Run("UCB1-Tuned explores no more than the largest variance allows", func(t *testing.T) {
+		got := UCB1Tuned{}.Value(100, volatile)
+
+		expected := 0.5 + 2*math.Sqrt(math.Log(100)/10*0.25)
+		require.InDelta(t, expected, got, 1e-9, "Should bound the variance of rewards between 0 and 1 by 1/4")
+	})
+
+	t.Run("progressive bias decays with visits", func(t *testing.T) {
+		policy := NewProgressiveBias(game.EvaluateResources)
+		biased := steady
+		biased.Bias = 0.5
+
+		require.InDelta(t, policy.UCT.Value(100, steady)+BiasWeight*0.5/11, policy.Value(100, biased), 1e-9)
+		require.Equal(t, policy.UCT.Value(100, steady), policy.Value(100, steady), "Should match UCT without bias")
+
+		visited := biased
+		visited.Rewards, visited.Squares, visited.Visits = 50, 100*0.25, 100
+		unbiased := visited
+		unbiased.Bias = 0
+		require.Less(t, policy.Value(100, visited)-policy.Value(100, unbiased), policy.Value(100, biased)-policy.Value(100, steady),
+			"Bias should weigh less as the child is visited")
+	})
+}
+
+func TestSimulateTreePolicies(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	policies := map[string]TreePolicy{
+		"uct":              UCT{CSquared: 1},
+		"puct":             PUCT{CPuct: CPuct},
+		"ucb1-tuned":       UCB1Tuned{},
+		"progressive-bias": NewProgressiveBias(game.EvaluateResources),
+	}
+
+	for name, policy := range policies {
+		mcts := NewMCTS(1, WithEpisodes(100), WithCutoff(20), WithSeed(1), WithTreePolicy(policy))
+
+		got, _ := mcts.Simulate(state, nil)
+
+		require.Len(t, got, len(state.LegalMoves()), "Should expand every move with %s", name)
+		total := 0.0
+		for _, visits := range got {
+			total += visits
+		}
+		require.Equal(t, 100.0, total, "Should visit a child on every episode with %s", name)
+		if _, ok := policy.(Biased); ok {
+			require.Len(t, mcts.root.biases, len(mcts.root.children), "Should score every expanded move with %s", name)
+		} else {
+			require.Nil(t, mcts.root.biases)
+		}
+	}
+}
+
+func TestWithSelectionPolicy(t *testing.T) {
+	for _, test := range []struct {
+		policy      SelectionPolicy
+		exploration float64
+		expected    TreePolicy
+	}{
+		{UCTSelection, 0, UCT{CSquared: CSquared}},
+		{UCTSelection, 1, UCT{CSquared: 1}},
+		{PUCTSelection, 0, PUCT{CPuct: CPuct}},
+		{PUCTSelection, 3, PUCT{CPuct: 3}},
+	} {
+		mcts := NewMCTS(1, WithEpisodes(1), WithSelectionPolicy(test.policy, test.exploration))
+
+		require.Equal(t, test.expected, mcts.selection.policy, "Should select by %+v", test)
+	}
+}
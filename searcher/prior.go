@@ -0,0 +1,107 @@
+package searcher
+
+import (
+	"cmp"
+	"math"
+	"risk/game"
+	"slices"
+)
+
+const Temperature = 0.25 // Temperature of the softmax of heuristic priors
+
+// Prior estimates the probability of each legal move of a state being the best,
+// guiding which moves a node expands first and, under PUCT, explores most
+type Prior interface {
+	// Probabilities returns the probability of each move, in the order of moves
+	Probabilities(state game.State, moves []game.Move) []float64
+}
+
+// UniformPrior deems every legal move equally likely to be the best
+type UniformPrior struct{}
+
+func (UniformPrior) Probabilities(state game.State, moves []game.Move) []float64 {
+	probabilities := make([]float64, len(moves))
+	for i := range probabilities {
+		probabilities[i] = 1 / float64(len(moves))
+	}
+	return probabilities
+}
+
+// HeuristicPrior deems moves more likely to be the best the better an
+// evaluation function scores the states they lead to, taking the softmax of the
+// scores from the perspective of the player to move
+type HeuristicPrior struct {
+	Evaluate    game.Evaluate
+	Temperature float64 // Lower temperatures favor the best scoring moves more
+}
+
+// NewHeuristicPrior returns a prior scoring moves with an evaluation function,
+// defaulting to the resources of players
+func NewHeuristicPrior(evaluate game.Evaluate) *HeuristicPrior {
+	if evaluate == nil {
+		evaluate = game.EvaluateResources
+	}
+	return &HeuristicPrior{Evaluate: evaluate, Temperature: Temperature}
+}
+
+func (h *HeuristicPrior) Probabilities(state game.State, moves []game.Move) []float64 {
+	scores := make([]float64, len(moves))
+	for i, move := range moves {
+		scores[i] = scoreMove(state, move, h.Evaluate)
+	}
+	return softmax(scores, h.Temperature)
+}
+
+// scoreMove evaluates the state a move leads to from the perspective of the
+// player to move, scoring stochastic moves by a single outcome
+func scoreMove(state game.State, move game.Move, evaluate game.Evaluate) float64 {
+	return scoreOutcome(state.Play(move), evaluate, state.Player())
+}
+
+// scoreOutcome evaluates the state a move led to from the perspective of the
+// given player
+func scoreOutcome(next game.State, evaluate game.Evaluate, perspective string) float64 {
+	if winner := next.Winner(); winner != "" {
+		return computeReward(winner, Win, perspective)
+	}
+	return computeReward(next.Player(), evaluate(next), perspective)
+}
+
+// softmax turns scores into probabilities, subtracting the highest score so
+// that the exponentials cannot overflow
+func softmax(scores []float64, temperature float64) []float64 {
+	if len(scores) == 0 {
+		return nil
+	}
+	highest := slices.Max(scores)
+	probabilities := make([]float64, len(scores))
+	total := 0.0
+	for i, score := range scores {
+		probabilities[i] = math.Exp((score - highest) / temperature)
+		total += probabilities[i]
+	}
+	for i := range probabilities {
+		probabilities[i] /= total
+	}
+	return probabilities
+}
+
+// sortByPrior orders moves from the most to the least probable, keeping the
+// order of equally probable moves, and returns their probabilities in that order
+func sortByPrior(moves []game.Move, probabilities []float64) []float64 {
+	order := make([]int, len(moves))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(probabilities[b], probabilities[a])
+	})
+
+	sorted := slices.Clone(moves)
+	priors := make([]float64, len(moves))
+	for i, index := range order {
+		moves[i] = sorted[index]
+		priors[i] = probabilities[index]
+	}
+	return priors
+}
@@ -0,0 +1,95 @@
+package searcher
+
+import (
+	"risk/game"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockPrior returns fixed probabilities whatever the state
+type mockPrior []float64
+
+func (m mockPrior) Probabilities(state game.State, moves []game.Move) []float64 {
+	return m
+}
+
+func TestUniformPrior(t *testing.T) {
+	moves := []game.Move{mockMove{id: 0}, mockMove{id: 1}, mockMove{id: 2}, mockMove{id: 3}}
+
+	got := UniformPrior{}.Probabilities(mockState{moves: moves}, moves)
+
+	require.Equal(t, []float64{0.25, 0.25, 0.25, 0.25}, got, "Should deem every move equally probable")
+}
+
+func TestHeuristicPrior(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	moves := state.LegalMoves()
+	prior := NewHeuristicPrior(game.EvaluateResources)
+
+	got := prior.Probabilities(state, moves)
+
+	require.Len(t, got, len(moves))
+	total := 0.0
+	for i, probability := range got {
+		require.Positive(t, probability)
+		total += probability
+		score := game.EvaluateResources(state.Play(moves[i]))
+		for j := range got {
+			if game.EvaluateResources(state.Play(moves[j])) < score {
+				require.Greater(t, probability, got[j], "Should deem moves scoring better more probable")
+			}
+		}
+	}
+	require.InDelta(t, 1.0, total, 1e-9, "Should sum to 1")
+}
+
+func TestSoftmax(t *testing.T) {
+	t.Run("normalizes scores", func(t *testing.T) {
+		got := softmax([]float64{1, 1}, 1)
+
+		require.Equal(t, []float64{0.5, 0.5}, got)
+	})
+
+	t.Run("favors the best scores more at lower temperatures", func(t *testing.T) {
+		hot := softmax([]float64{1, 0}, 1)
+		cold := softmax([]float64{1, 0}, 0.1)
+
+		require.Greater(t, cold[0], hot[0])
+		require.Greater(t, hot[0], 0.5)
+	})
+
+	t.Run("does not overflow", func(t *testing.T) {
+		got := softmax([]float64{1000, 1000}, 0.01)
+
+		require.Equal(t, []float64{0.5, 0.5}, got)
+	})
+}
+
+func TestSimulatePUCT(t *testing.T) {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+
+	t.Run("defaults to a uniform prior", func(t *testing.T) {
+		mcts := NewMCTS(1, WithEpisodes(100), WithSeed(1), WithTreePolicy(PUCT{CPuct: CPuct}))
+
+		require.Equal(t, &selection{policy: PUCT{CPuct: CPuct}, prior: UniformPrior{}}, mcts.selection)
+
+		got, _ := mcts.Simulate(state, nil)
+
+		require.Len(t, got, len(state.LegalMoves()), "Should expand every move")
+		require.Equal(t, state.LegalMoves(), mcts.root.explored, "Should expand equally probable moves in order")
+	})
+
+	t.Run("expands the moves the prior favors first", func(t *testing.T) {
+		prior := NewHeuristicPrior(game.EvaluateResources)
+		mcts := NewMCTS(1, WithEpisodes(200), WithSeed(1), WithTreePolicy(PUCT{CPuct: 1}), WithPrior(prior))
+
+		got, _ := mcts.Simulate(state, nil)
+
+		require.Len(t, got, len(state.LegalMoves()), "Should expand every move")
+		require.Len(t, mcts.root.priors, len(state.LegalMoves()))
+		require.IsNonIncreasing(t, mcts.root.priors, "Should expand moves from the most probable")
+		probabilities := prior.Probabilities(state, mcts.root.explored)
+		require.InDeltaSlice(t, probabilities, mcts.root.priors, 1e-9, "Should store the prior of each explored move")
+	})
+}
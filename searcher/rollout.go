@@ -0,0 +1,148 @@
+package searcher
+
+import (
+	"math"
+	"math/rand"
+	"risk/game"
+)
+
+const Epsilon = 0.1 // Chance of epsilon-greedy rollouts playing a random move
+
+const FavorableRatio = 2.0 // Troop ratio from which an attack is deemed favorable
+
+// DefaultFeatureWeights weigh the features of moves for softmax rollouts,
+// favoring favorable attacks, trading in cards, and moving troops in bulk to
+// the front
+var DefaultFeatureWeights = [game.NumMoveFeatures]float64{
+	game.AttackFeature:   2,
+	game.PassFeature:     0,
+	game.TradeFeature:    2,
+	game.TroopsFeature:   1,
+	game.BorderFeature:   1,
+	game.InteriorFeature: 1,
+}
+
+// RolloutPolicy chooses the moves of rollouts. Policies are shared by the
+// goroutines of a search, so they draw randomness from the given source only.
+type RolloutPolicy interface {
+	// Choose returns the index of the move to play among the legal moves of a
+	// state, which may be a view of a state played in place
+	Choose(state game.State, moves []game.Move, rng *rand.Rand) int
+}
+
+// UniformRollout plays legal moves uniformly at random
+type UniformRollout struct{}
+
+func (UniformRollout) Choose(state game.State, moves []game.Move, rng *rand.Rand) int {
+	return rng.Intn(len(moves))
+}
+
+// EpsilonGreedyRollout plays the move whose resulting state evaluates best for
+// the player to move, or a random move with probability epsilon. Looking a move
+// ahead copies the state for every legal move, trading playouts for their
+// quality.
+type EpsilonGreedyRollout struct {
+	Epsilon  float64
+	Evaluate game.Evaluate
+}
+
+// NewEpsilonGreedyRollout returns an epsilon-greedy policy evaluating moves with
+// the given function, defaulting to the resources of players
+func NewEpsilonGreedyRollout(epsilon float64, evaluate game.Evaluate) *EpsilonGreedyRollout {
+	if evaluate == nil {
+		evaluate = game.EvaluateResources
+	}
+	return &EpsilonGreedyRollout{Epsilon: epsilon, Evaluate: evaluate}
+}
+
+func (e *EpsilonGreedyRollout) Choose(state game.State, moves []game.Move, rng *rand.Rand) int {
+	if len(moves) == 1 || rng.Float64() < e.Epsilon {
+		return rng.Intn(len(moves))
+	}
+	best, bestScore, ties := 0, math.Inf(-1), 0
+	for i, move := range moves {
+		score := scoreMove(state, move, e.Evaluate)
+		if score > bestScore {
+			best, bestScore, ties = i, score, 1
+		} else if score == bestScore { // Break ties at random
+			ties++
+			if rng.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// FavorableAttackRollout attacks with the highest troop ratio once the ratio is
+// favorable, and otherwise refrains from attacking. Moves of other phases are
+// played uniformly at random.
+type FavorableAttackRollout struct {
+	Ratio float64 // Troop ratio from which an attack is deemed favorable
+}
+
+func (f FavorableAttackRollout) Choose(state game.State, moves []game.Move, rng *rand.Rand) int {
+	gs, ok := state.(*game.GameState)
+	if !ok || gs.Phase != game.AttackPhase {
+		return rng.Intn(len(moves))
+	}
+	best, bestRatio := -1, f.Ratio
+	var others []int
+	for i, move := range moves {
+		move := move.(*game.GameMove)
+		if move.ActionType != game.AttackAction {
+			others = append(others, i)
+			continue
+		}
+		if ratio := gs.AttackRatio(move); ratio >= bestRatio {
+			best, bestRatio = i, ratio
+		}
+	}
+	if best >= 0 {
+		return best
+	}
+	if len(others) > 0 {
+		return others[rng.Intn(len(others))]
+	}
+	return rng.Intn(len(moves))
+}
+
+// SoftmaxRollout plays moves with probabilities given by the softmax of the
+// weighted features of moves
+type SoftmaxRollout struct {
+	Weights     [game.NumMoveFeatures]float64
+	Temperature float64 // Lower temperatures favor the best weighted moves more
+}
+
+// NewSoftmaxRollout returns a softmax policy with the default feature weights
+func NewSoftmaxRollout() *SoftmaxRollout {
+	return &SoftmaxRollout{Weights: DefaultFeatureWeights, Temperature: 1}
+}
+
+func (s *SoftmaxRollout) Choose(state game.State, moves []game.Move, rng *rand.Rand) int {
+	gs, ok := state.(*game.GameState)
+	if !ok {
+		return rng.Intn(len(moves))
+	}
+	scores := make([]float64, len(moves))
+	for i, move := range moves {
+		features := gs.MoveFeatures(move.(*game.GameMove))
+		for feature, value := range features {
+			scores[i] += s.Weights[feature] * value
+		}
+	}
+	return sample(softmax(scores, s.Temperature), rng)
+}
+
+// sample draws an index with the given probabilities
+func sample(probabilities []float64, rng *rand.Rand) int {
+	sampled := rng.Float64()
+	cumulative := 0.0
+	for i, probability := range probabilities {
+		cumulative += probability
+		if sampled < cumulative {
+			return i
+		}
+	}
+	return len(probabilities) - 1 // Fall back in case of rounding errors
+}
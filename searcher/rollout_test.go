@@ -0,0 +1,107 @@
+package searcher
+
+import (
+	"math/rand"
+	"risk/experiments/metrics"
+	"risk/game"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newAttackState plays random moves till the player to move can attack
+func newAttackState(t *testing.T) *game.GameState {
+	state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+	rng := rand.New(rand.NewSource(1))
+	for state.Phase != game.AttackPhase || len(state.LegalMoves()) < 3 {
+		moves := state.LegalMoves()
+		state = state.Play(moves[rng.Intn(len(moves))]).(*game.GameState)
+	}
+	require.Equal(t, game.AttackPhase, state.Phase)
+	return state
+}
+
+func TestRolloutPolicies(t *testing.T) {
+	policies := map[string]RolloutPolicy{
+		"uniform":           UniformRollout{},
+		"epsilon-greedy":    NewEpsilonGreedyRollout(Epsilon, game.EvaluateResources),
+		"favorable-attacks": FavorableAttackRollout{Ratio: FavorableRatio},
+		"softmax":           NewSoftmaxRollout(),
+	}
+
+	t.Run("plays uniformly at random by default", func(t *testing.T) {
+		state := newAttackState(t)
+		moves := state.LegalMoves()
+
+		got := UniformRollout{}.Choose(state, moves, rand.New(rand.NewSource(1)))
+
+		require.Equal(t, rand.New(rand.NewSource(1)).Intn(len(moves)), got, "Should draw like random rollouts did")
+	})
+
+	t.Run("plays the best evaluated move greedily", func(t *testing.T) {
+		state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+		moves := state.LegalMoves() // Reinforcements, whose outcome is certain
+		policy := NewEpsilonGreedyRollout(0, game.EvaluateResources)
+
+		got := policy.Choose(state, moves, rand.New(rand.NewSource(1)))
+
+		best := scoreMove(state, moves[got], game.EvaluateResources)
+		for _, move := range moves {
+			require.LessOrEqual(t, scoreMove(state, move, game.EvaluateResources), best, "Should play the best scoring move")
+		}
+	})
+
+	t.Run("attacks only with a favorable troop ratio", func(t *testing.T) {
+		state := newAttackState(t)
+		moves := state.LegalMoves()
+		bestRatio := 0.0
+		for _, move := range moves {
+			if move := move.(*game.GameMove); move.ActionType == game.AttackAction {
+				bestRatio = max(bestRatio, state.AttackRatio(move))
+			}
+		}
+
+		favorable := moves[FavorableAttackRollout{Ratio: bestRatio}.Choose(state, moves, rand.New(rand.NewSource(1)))].(*game.GameMove)
+		unfavorable := moves[FavorableAttackRollout{Ratio: bestRatio + 1}.Choose(state, moves, rand.New(rand.NewSource(1)))].(*game.GameMove)
+
+		require.Equal(t, game.AttackAction, favorable.ActionType)
+		require.Equal(t, bestRatio, state.AttackRatio(favorable), "Should attack with the highest troop ratio")
+		require.Equal(t, game.PassAction, unfavorable.ActionType, "Should refrain from unfavorable attacks")
+	})
+
+	t.Run("plays moves by the softmax of their weighted features", func(t *testing.T) {
+		state := newAttackState(t)
+		moves := state.LegalMoves()
+		policy := &SoftmaxRollout{Temperature: 0.01}
+		policy.Weights[game.PassFeature] = 10
+
+		got := moves[policy.Choose(state, moves, rand.New(rand.NewSource(1)))].(*game.GameMove)
+
+		require.Equal(t, game.PassAction, got.ActionType, "Should play the move of much higher weight")
+	})
+
+	t.Run("rolls out in place like copying states", func(t *testing.T) {
+		state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+		for name, policy := range policies {
+			copied := state.UseRand(rand.New(rand.NewSource(2)))
+			inPlace := state.UseRand(rand.New(rand.NewSource(2))).(rollouter).Rollout()
+
+			expectedPlayer, expectedScore := rollout(copied, rand.New(rand.NewSource(3)), 50, game.EvaluateResources, policy, metrics.NewDummyCollector())
+			player, score := rolloutInPlace(inPlace, rand.New(rand.NewSource(3)), 50, game.EvaluateResources, policy, metrics.NewDummyCollector())
+
+			require.Equal(t, expectedPlayer, player, "Should reach the same state with the %s policy", name)
+			require.Equal(t, expectedScore, score, "Should reach the same state with the %s policy", name)
+		}
+	})
+
+	t.Run("searches with every policy", func(t *testing.T) {
+		state := game.NewGameState(game.CreateMap(), game.NewStandardRules(), game.WithPlayers(3), game.WithSeed(1))
+		for name, policy := range policies {
+			mcts := NewMCTS(2, WithEpisodes(20), WithCutoff(50), WithRolloutPolicy(policy))
+
+			got, _ := mcts.Simulate(state, nil)
+
+			require.NotEmpty(t, got, "Should search with the %s policy", name)
+		}
+	})
+}